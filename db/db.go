@@ -0,0 +1,17 @@
+package db
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import "embed"
+
+//Migrations holds the migration scripts for each database variant.
+//The MySql scripts are in the migrations directory, the others in a directory named for the database
+//
+//go:embed migrations/*.sql postgres/*.sql sqlite/*.sql
+var Migrations embed.FS
@@ -1,5 +1,3 @@
-use sa_accounts;
-
 # Simple Accounts
 
 CREATE TABLE `sa_ac_type`
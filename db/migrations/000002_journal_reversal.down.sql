@@ -0,0 +1,3 @@
+ALTER TABLE `sa_journal`
+    DROP COLUMN `reversalOf`,
+    DROP COLUMN `reversedBy`;
@@ -0,0 +1,6 @@
+# Journal reversal. A reversing journal is linked to the journal it reverses,
+# which is then void
+
+ALTER TABLE `sa_journal`
+    ADD COLUMN `reversalOf` int(10) unsigned DEFAULT NULL COMMENT 'id of the journal that this journal reverses',
+    ADD COLUMN `reversedBy` int(10) unsigned DEFAULT NULL COMMENT 'id of the journal that reverses this journal. Set if this journal is void';
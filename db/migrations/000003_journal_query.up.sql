@@ -0,0 +1,5 @@
+# Indexes for journal queries, which page through the journals of a chart in date order
+# and filter them by the nominals of their entries
+
+CREATE INDEX `sa_journal_chart_date_idx` ON `sa_journal` (`chartId`, `date`, `id`);
+CREATE INDEX `sa_journal_entry_nominal_idx` ON `sa_journal_entry` (`nominal`, `jrnId`);
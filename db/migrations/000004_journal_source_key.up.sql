@@ -0,0 +1,6 @@
+# Idempotent journals. A journal written idempotently has srcKey set, so that its src and ref
+# are unique within the chart. Null keys are never equal, so other journals are not constrained
+
+ALTER TABLE `sa_journal`
+    ADD COLUMN `srcKey` tinyint(1) DEFAULT NULL COMMENT '1 if src and ref are a unique key for the journal in its chart';
+CREATE UNIQUE INDEX `sa_journal_src_key_uindex` ON `sa_journal` (`chartId`, `src`, `ref`, `srcKey`);
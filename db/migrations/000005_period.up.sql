@@ -0,0 +1,15 @@
+# Accounting periods. Journals cannot be written with a date in a closed or locked period
+
+CREATE TABLE `sa_period`
+(
+    `id`        int(10) unsigned NOT NULL AUTO_INCREMENT COMMENT 'internal id of the period',
+    `chartId`   int(10) unsigned NOT NULL COMMENT 'the chart to which this period belongs',
+    `name`      varchar(30)      NOT NULL COMMENT 'name of period',
+    `startDate` datetime         NOT NULL COMMENT 'start of the period',
+    `endDate`   datetime         NOT NULL COMMENT 'end of the period, which is the start of the next period',
+    `status`    varchar(6)       NOT NULL DEFAULT 'open' COMMENT 'open, closed or locked',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `sa_period_chartId_startDate_uindex` (`chartId`, `startDate`),
+    CONSTRAINT `sa_period_sa_coa_id_fk` FOREIGN KEY (`chartId`) REFERENCES `sa_coa` (`id`) ON DELETE CASCADE
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8 COMMENT ='Accounting periods';
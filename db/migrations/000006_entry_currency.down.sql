@@ -0,0 +1,4 @@
+ALTER TABLE `sa_journal_entry`
+    DROP COLUMN `crcy`,
+    DROP COLUMN `crcyAmount`,
+    DROP COLUMN `rate`;
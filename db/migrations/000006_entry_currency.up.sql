@@ -0,0 +1,7 @@
+# Multi-currency entries. acDr and acCr are always in the chart currency. An entry in another currency
+# also records the transaction currency, the amount in that currency and the rate used to convert it
+
+ALTER TABLE `sa_journal_entry`
+    ADD COLUMN `crcy`       char(3)    DEFAULT NULL COMMENT 'transaction currency code, null if the chart currency',
+    ADD COLUMN `crcyAmount` bigint(20) DEFAULT '0' COMMENT 'amount in the transaction currency',
+    ADD COLUMN `rate`       double     DEFAULT '0' COMMENT 'rate used to convert the transaction currency amount to the chart currency';
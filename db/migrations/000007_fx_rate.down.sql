@@ -0,0 +1 @@
+DROP TABLE IF EXISTS `sa_fx_rate`;
@@ -0,0 +1,13 @@
+# Exchange rates. A rate converts an amount in fromCrcy to toCrcy, from its rateDate until the next rate for the pair
+
+CREATE TABLE `sa_fx_rate`
+(
+    `id`       int(10) unsigned NOT NULL AUTO_INCREMENT COMMENT 'internal id of the rate',
+    `fromCrcy` char(3)          NOT NULL COMMENT 'currency code converted from',
+    `toCrcy`   char(3)          NOT NULL COMMENT 'currency code converted to',
+    `rateDate` datetime         NOT NULL COMMENT 'date from which the rate applies',
+    `rate`     double           NOT NULL COMMENT 'amount of toCrcy for one unit of fromCrcy',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `sa_fx_rate_pair_date_uindex` (`fromCrcy`, `toCrcy`, `rateDate`)
+) ENGINE = InnoDB
+  DEFAULT CHARSET = utf8 COMMENT ='Exchange rates';
@@ -0,0 +1,7 @@
+# Chart metadata. The currency of a chart is its base currency, in which all ledger values are held
+
+ALTER TABLE `sa_coa`
+    ADD COLUMN `crcy`            char(3)      DEFAULT NULL COMMENT 'base currency code of chart',
+    ADD COLUMN `description`     varchar(255) NOT NULL DEFAULT '' COMMENT 'description of chart',
+    ADD COLUMN `created`         datetime     DEFAULT NULL COMMENT 'time the chart was created',
+    ADD COLUMN `fiscalYearStart` datetime     DEFAULT NULL COMMENT 'start of the first fiscal year of the chart';
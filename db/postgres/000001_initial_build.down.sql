@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS sa_journal_entry;
+DROP TABLE IF EXISTS sa_journal;
+DROP TABLE IF EXISTS sa_coa_ledger;
+DROP TABLE IF EXISTS sa_ac_type;
+DROP TABLE IF EXISTS sa_coa;
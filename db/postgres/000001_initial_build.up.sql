@@ -0,0 +1,84 @@
+-- Simple Accounts for PostgreSQL
+-- Table layout is the same as the MySql variant. The stored routines and trigger
+-- of the MySql variant are implemented in Go by sa.PostgresStore
+
+CREATE TABLE sa_ac_type
+(
+    type  varchar(10) NOT NULL,
+    value smallint    NOT NULL,
+    CONSTRAINT sa_ac_type_pk PRIMARY KEY (type)
+);
+COMMENT ON TABLE sa_ac_type IS 'Account type enumeration';
+INSERT INTO sa_ac_type (type, value)
+VALUES ('ASSET', 11),
+       ('BANK', 27),
+       ('CR', 5),
+       ('CUSTOMER', 44),
+       ('DR', 3),
+       ('DUMMY', 0),
+       ('EQUITY', 645),
+       ('EXPENSE', 77),
+       ('INCOME', 389),
+       ('LIABILITY', 133),
+       ('REAL', 1),
+       ('SUPPLIER', 1157);
+
+CREATE TABLE sa_coa
+(
+    id   serial      NOT NULL,
+    name varchar(20) NOT NULL,
+    CONSTRAINT sa_coa_pk PRIMARY KEY (id),
+    CONSTRAINT sa_coa_name_uindex UNIQUE (name)
+);
+COMMENT ON TABLE sa_coa IS 'A Chart of Account';
+
+CREATE TABLE sa_coa_ledger
+(
+    id      serial      NOT NULL,
+    prntId  integer     NOT NULL DEFAULT 0,
+    lft     integer     NOT NULL DEFAULT 0,
+    rgt     integer     NOT NULL DEFAULT 0,
+    chartId integer              DEFAULT NULL,
+    nominal varchar(10) NOT NULL,
+    type    varchar(10)          DEFAULT NULL,
+    name    varchar(30) NOT NULL,
+    acDr    bigint      NOT NULL DEFAULT 0,
+    acCr    bigint      NOT NULL DEFAULT 0,
+    CONSTRAINT sa_coa_ledger_pk PRIMARY KEY (id),
+    CONSTRAINT sa_coa_ledger_chartId_nominal_index UNIQUE (chartId, nominal),
+    CONSTRAINT sa_coa_ledger_sa_ac_type_type_fk FOREIGN KEY (type) REFERENCES sa_ac_type (type) ON DELETE CASCADE,
+    CONSTRAINT sa_coa_ledger_sa_coa_fk FOREIGN KEY (chartId) REFERENCES sa_coa (id) ON DELETE CASCADE
+);
+COMMENT ON TABLE sa_coa_ledger IS 'Chart of Account structure';
+CREATE INDEX sa_coa_ledger_sa_ac_type_type_idx ON sa_coa_ledger (type);
+CREATE INDEX sa_coa_ledger_sa_coa_idx ON sa_coa_ledger (chartId);
+CREATE INDEX sa_coa_ledger_lft_idx ON sa_coa_ledger (lft);
+CREATE INDEX sa_coa_ledger_rgt_idx ON sa_coa_ledger (rgt);
+
+CREATE TABLE sa_journal
+(
+    id      serial  NOT NULL,
+    chartId integer NOT NULL,
+    note    text,
+    date    timestamp DEFAULT CURRENT_TIMESTAMP,
+    src     varchar(6),
+    ref     bigint,
+    CONSTRAINT sa_journal_pk PRIMARY KEY (id),
+    CONSTRAINT sa_journal_sa_coa_id_fk FOREIGN KEY (chartId) REFERENCES sa_coa (id) ON DELETE CASCADE
+);
+COMMENT ON TABLE sa_journal IS 'Txn Journal Header';
+CREATE INDEX sa_journal_sa_coa_id_idx ON sa_journal (chartId);
+CREATE INDEX sa_journal_external_reference ON sa_journal (src, ref);
+
+CREATE TABLE sa_journal_entry
+(
+    id      serial      NOT NULL,
+    jrnId   integer DEFAULT NULL,
+    nominal varchar(10) NOT NULL,
+    acDr    bigint  DEFAULT 0,
+    acCr    bigint  DEFAULT 0,
+    CONSTRAINT sa_journal_entry_pk PRIMARY KEY (id),
+    CONSTRAINT sa_journal_entry_sa_jrn_id_fk FOREIGN KEY (jrnId) REFERENCES sa_journal (id) ON DELETE CASCADE
+);
+COMMENT ON TABLE sa_journal_entry IS 'Txn Journal Entry';
+CREATE INDEX sa_journal_entry_sa_jrn_id_idx ON sa_journal_entry (jrnId);
@@ -0,0 +1,8 @@
+-- Journal reversal. A reversing journal is linked to the journal it reverses,
+-- which is then void
+
+ALTER TABLE sa_journal
+    ADD COLUMN reversalOf integer DEFAULT NULL,
+    ADD COLUMN reversedBy integer DEFAULT NULL;
+COMMENT ON COLUMN sa_journal.reversalOf IS 'id of the journal that this journal reverses';
+COMMENT ON COLUMN sa_journal.reversedBy IS 'id of the journal that reverses this journal. Set if this journal is void';
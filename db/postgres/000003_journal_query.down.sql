@@ -0,0 +1,2 @@
+DROP INDEX sa_journal_entry_nominal_idx;
+DROP INDEX sa_journal_chart_date_idx;
@@ -0,0 +1,3 @@
+DROP INDEX sa_journal_src_key_uindex;
+ALTER TABLE sa_journal
+    DROP COLUMN srcKey;
@@ -0,0 +1,17 @@
+-- Accounting periods. Journals cannot be written with a date in a closed or locked period
+
+CREATE TABLE sa_period
+(
+    id        serial      NOT NULL,
+    chartId   integer     NOT NULL,
+    name      varchar(30) NOT NULL,
+    startDate timestamp   NOT NULL,
+    endDate   timestamp   NOT NULL,
+    status    varchar(6)  NOT NULL DEFAULT 'open',
+    CONSTRAINT sa_period_pk PRIMARY KEY (id),
+    CONSTRAINT sa_period_chartId_startDate_uindex UNIQUE (chartId, startDate),
+    CONSTRAINT sa_period_sa_coa_id_fk FOREIGN KEY (chartId) REFERENCES sa_coa (id) ON DELETE CASCADE
+);
+COMMENT ON TABLE sa_period IS 'Accounting periods';
+COMMENT ON COLUMN sa_period.endDate IS 'end of the period, which is the start of the next period';
+COMMENT ON COLUMN sa_period.status IS 'open, closed or locked';
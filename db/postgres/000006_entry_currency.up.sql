@@ -0,0 +1,10 @@
+-- Multi-currency entries. acDr and acCr are always in the chart currency. An entry in another currency
+-- also records the transaction currency, the amount in that currency and the rate used to convert it
+
+ALTER TABLE sa_journal_entry
+    ADD COLUMN crcy       char(3)          DEFAULT NULL,
+    ADD COLUMN crcyAmount bigint           DEFAULT 0,
+    ADD COLUMN rate       double precision DEFAULT 0;
+COMMENT ON COLUMN sa_journal_entry.crcy IS 'transaction currency code, null if the chart currency';
+COMMENT ON COLUMN sa_journal_entry.crcyAmount IS 'amount in the transaction currency';
+COMMENT ON COLUMN sa_journal_entry.rate IS 'rate used to convert the transaction currency amount to the chart currency';
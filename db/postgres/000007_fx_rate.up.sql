@@ -0,0 +1,15 @@
+-- Exchange rates. A rate converts an amount in fromCrcy to toCrcy, from its rateDate until the next rate for the pair
+
+CREATE TABLE sa_fx_rate
+(
+    id       serial           NOT NULL,
+    fromCrcy char(3)          NOT NULL,
+    toCrcy   char(3)          NOT NULL,
+    rateDate timestamp        NOT NULL,
+    rate     double precision NOT NULL,
+    CONSTRAINT sa_fx_rate_pk PRIMARY KEY (id),
+    CONSTRAINT sa_fx_rate_pair_date_uindex UNIQUE (fromCrcy, toCrcy, rateDate)
+);
+COMMENT ON TABLE sa_fx_rate IS 'Exchange rates';
+COMMENT ON COLUMN sa_fx_rate.rateDate IS 'date from which the rate applies';
+COMMENT ON COLUMN sa_fx_rate.rate IS 'amount of toCrcy for one unit of fromCrcy';
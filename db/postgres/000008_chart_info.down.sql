@@ -0,0 +1,5 @@
+ALTER TABLE sa_coa
+    DROP COLUMN crcy,
+    DROP COLUMN description,
+    DROP COLUMN created,
+    DROP COLUMN fiscalYearStart;
@@ -0,0 +1,9 @@
+-- Chart metadata. The currency of a chart is its base currency, in which all ledger values are held
+
+ALTER TABLE sa_coa
+    ADD COLUMN crcy            char(3)      DEFAULT NULL,
+    ADD COLUMN description     varchar(255) NOT NULL DEFAULT '',
+    ADD COLUMN created         timestamp    DEFAULT NULL,
+    ADD COLUMN fiscalYearStart timestamp    DEFAULT NULL;
+COMMENT ON COLUMN sa_coa.crcy IS 'base currency code of chart';
+COMMENT ON COLUMN sa_coa.fiscalYearStart IS 'start of the first fiscal year of the chart';
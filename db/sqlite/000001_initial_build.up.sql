@@ -0,0 +1,71 @@
+-- Simple Accounts for SQLite
+-- Table layout is compatible with the MySql variant. The stored routines and trigger
+-- of the MySql variant are implemented in Go by sa.SqliteStore
+-- Foreign keys are only enforced if the connection has them turned on, e.g. file:accounts.db?_foreign_keys=1
+
+CREATE TABLE sa_ac_type
+(
+    type  varchar(10) NOT NULL PRIMARY KEY, -- External value of account type
+    value smallint    NOT NULL              -- Internal value of account type
+);
+INSERT INTO sa_ac_type (type, value)
+VALUES ('ASSET', 11),
+       ('BANK', 27),
+       ('CR', 5),
+       ('CUSTOMER', 44),
+       ('DR', 3),
+       ('DUMMY', 0),
+       ('EQUITY', 645),
+       ('EXPENSE', 77),
+       ('INCOME', 389),
+       ('LIABILITY', 133),
+       ('REAL', 1),
+       ('SUPPLIER', 1157);
+
+CREATE TABLE sa_coa
+(
+    id   integer     NOT NULL PRIMARY KEY AUTOINCREMENT, -- id of chart
+    name varchar(20) NOT NULL                            -- name of chart
+);
+CREATE UNIQUE INDEX sa_coa_name_uindex ON sa_coa (name);
+
+CREATE TABLE sa_coa_ledger
+(
+    id      integer     NOT NULL PRIMARY KEY AUTOINCREMENT,                -- internal ledger id
+    prntId  integer     NOT NULL DEFAULT 0,                                -- parent node internal id
+    lft     integer     NOT NULL DEFAULT 0,                                -- left node internal id
+    rgt     integer     NOT NULL DEFAULT 0,                                -- right node internal id
+    chartId integer              DEFAULT NULL REFERENCES sa_coa (id) ON DELETE CASCADE, -- id of chart that this account belongs to
+    nominal char(10)    NOT NULL,                                          -- nominal id for this account
+    type    varchar(10)          DEFAULT NULL REFERENCES sa_ac_type (type) ON DELETE CASCADE, -- type of account
+    name    varchar(30) NOT NULL,                                          -- name of account
+    acDr    bigint      NOT NULL DEFAULT 0,                                -- debit amount
+    acCr    bigint      NOT NULL DEFAULT 0                                 -- credit amount
+);
+CREATE UNIQUE INDEX sa_coa_ledger_chartId_nominal_index ON sa_coa_ledger (chartId, nominal);
+CREATE INDEX sa_coa_ledger_sa_ac_type_type_fk ON sa_coa_ledger (type);
+CREATE INDEX sa_coa_ledger_sa_coa_fk ON sa_coa_ledger (chartId);
+CREATE INDEX sa_coa_ledger_lft_idx ON sa_coa_ledger (lft);
+CREATE INDEX sa_coa_ledger_rgt_idx ON sa_coa_ledger (rgt);
+
+CREATE TABLE sa_journal
+(
+    id      integer NOT NULL PRIMARY KEY AUTOINCREMENT,                -- internal id of the journal
+    chartId integer NOT NULL REFERENCES sa_coa (id) ON DELETE CASCADE, -- the chart to which this journal belongs
+    note    text,                                                      -- a note for the journal entry
+    date    datetime DEFAULT CURRENT_TIMESTAMP,                        -- timestamp for this journal
+    src     varchar(6),                                                -- user defined source of journal
+    ref     integer                                                    -- user defined reference to this journal
+);
+CREATE INDEX sa_journal_sa_coa_id_fk ON sa_journal (chartId);
+CREATE INDEX sa_journal_external_reference ON sa_journal (src, ref);
+
+CREATE TABLE sa_journal_entry
+(
+    id      integer     NOT NULL PRIMARY KEY AUTOINCREMENT,                      -- internal id for entry
+    jrnId   integer DEFAULT NULL REFERENCES sa_journal (id) ON DELETE CASCADE, -- id of journal that this entry belongs to
+    nominal varchar(10) NOT NULL,                                               -- nominal code for entry
+    acDr    bigint  DEFAULT 0,                                                  -- debit amount for entry
+    acCr    bigint  DEFAULT 0                                                   -- credit amount for entry
+);
+CREATE INDEX sa_journal_entry_sa_org_id_fk ON sa_journal_entry (jrnId);
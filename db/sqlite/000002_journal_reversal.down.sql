@@ -0,0 +1,2 @@
+ALTER TABLE sa_journal DROP COLUMN reversedBy;
+ALTER TABLE sa_journal DROP COLUMN reversalOf;
@@ -0,0 +1,5 @@
+-- Journal reversal. A reversing journal is linked to the journal it reverses,
+-- which is then void
+
+ALTER TABLE sa_journal ADD COLUMN reversalOf integer DEFAULT NULL; -- id of the journal that this journal reverses
+ALTER TABLE sa_journal ADD COLUMN reversedBy integer DEFAULT NULL; -- id of the journal that reverses this journal. Set if this journal is void
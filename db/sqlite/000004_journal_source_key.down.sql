@@ -0,0 +1,2 @@
+DROP INDEX sa_journal_src_key_uindex;
+ALTER TABLE sa_journal DROP COLUMN srcKey;
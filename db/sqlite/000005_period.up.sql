@@ -0,0 +1,12 @@
+-- Accounting periods. Journals cannot be written with a date in a closed or locked period
+
+CREATE TABLE sa_period
+(
+    id        integer     NOT NULL PRIMARY KEY AUTOINCREMENT,                -- internal id of the period
+    chartId   integer     NOT NULL REFERENCES sa_coa (id) ON DELETE CASCADE, -- the chart to which this period belongs
+    name      varchar(30) NOT NULL,                                          -- name of period
+    startDate datetime    NOT NULL,                                          -- start of the period
+    endDate   datetime    NOT NULL,                                          -- end of the period, which is the start of the next period
+    status    varchar(6)  NOT NULL DEFAULT 'open'                            -- open, closed or locked
+);
+CREATE UNIQUE INDEX sa_period_chartId_startDate_uindex ON sa_period (chartId, startDate);
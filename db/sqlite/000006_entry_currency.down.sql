@@ -0,0 +1,3 @@
+ALTER TABLE sa_journal_entry DROP COLUMN crcy;
+ALTER TABLE sa_journal_entry DROP COLUMN crcyAmount;
+ALTER TABLE sa_journal_entry DROP COLUMN rate;
@@ -0,0 +1,6 @@
+-- Multi-currency entries. acDr and acCr are always in the chart currency. An entry in another currency
+-- also records the transaction currency, the amount in that currency and the rate used to convert it
+
+ALTER TABLE sa_journal_entry ADD COLUMN crcy char(3) DEFAULT NULL; -- transaction currency code, null if the chart currency
+ALTER TABLE sa_journal_entry ADD COLUMN crcyAmount bigint DEFAULT 0; -- amount in the transaction currency
+ALTER TABLE sa_journal_entry ADD COLUMN rate real DEFAULT 0; -- rate used to convert the transaction currency amount to the chart currency
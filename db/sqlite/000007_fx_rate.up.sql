@@ -0,0 +1,11 @@
+-- Exchange rates. A rate converts an amount in fromCrcy to toCrcy, from its rateDate until the next rate for the pair
+
+CREATE TABLE sa_fx_rate
+(
+    id       integer  NOT NULL PRIMARY KEY AUTOINCREMENT, -- internal id of the rate
+    fromCrcy char(3)  NOT NULL,                           -- currency code converted from
+    toCrcy   char(3)  NOT NULL,                           -- currency code converted to
+    rateDate datetime NOT NULL,                           -- date from which the rate applies
+    rate     real     NOT NULL                            -- amount of toCrcy for one unit of fromCrcy
+);
+CREATE UNIQUE INDEX sa_fx_rate_pair_date_uindex ON sa_fx_rate (fromCrcy, toCrcy, rateDate);
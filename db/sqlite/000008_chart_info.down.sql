@@ -0,0 +1,4 @@
+ALTER TABLE sa_coa DROP COLUMN crcy;
+ALTER TABLE sa_coa DROP COLUMN description;
+ALTER TABLE sa_coa DROP COLUMN created;
+ALTER TABLE sa_coa DROP COLUMN fiscalYearStart;
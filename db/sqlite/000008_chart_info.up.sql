@@ -0,0 +1,6 @@
+-- Chart metadata. The currency of a chart is its base currency, in which all ledger values are held
+
+ALTER TABLE sa_coa ADD COLUMN crcy char(3) DEFAULT NULL; -- base currency code of chart
+ALTER TABLE sa_coa ADD COLUMN description varchar(255) NOT NULL DEFAULT ''; -- description of chart
+ALTER TABLE sa_coa ADD COLUMN created datetime DEFAULT NULL; -- time the chart was created
+ALTER TABLE sa_coa ADD COLUMN fiscalYearStart datetime DEFAULT NULL; -- start of the first fiscal year of the chart
@@ -7,6 +7,8 @@ require (
 	github.com/go-sql-driver/mysql v1.6.0
 	github.com/jbussdieker/golibxml v0.0.0-20190103165431-90c340ae5026
 	github.com/krolaw/xsd v0.0.0-20190108013600-03ca754cf4c5
+	github.com/lib/pq v1.10.9
+	github.com/mattn/go-sqlite3 v1.14.17
 	github.com/stretchr/testify v1.8.0
 	github.com/subchen/go-xmldom v1.1.2
 )
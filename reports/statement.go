@@ -0,0 +1,322 @@
+package reports
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"context"
+	"fmt"
+	"github.com/chippyash/go-hierarchy-tree/tree"
+	"github.com/chippyash/go-simple-accounts/sa"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+//Account type names for each part of the financial statements
+var (
+	assetTypes     = map[string]bool{"ASSET": true, "BANK": true, "CUSTOMER": true}
+	liabilityTypes = map[string]bool{"LIABILITY": true, "SUPPLIER": true}
+	equityTypes    = map[string]bool{"EQUITY": true}
+	incomeTypes    = map[string]bool{"INCOME": true}
+	expenseTypes   = map[string]bool{"EXPENSE": true}
+)
+
+//NetProfitName is the name of the line that carries net profit into equity on a BalanceSheet
+const NetProfitName = "Net Profit"
+
+//StatementLine is an account in a financial statement.
+//Balance is the account balance including all of its child accounts, so it is the subtotal of Lines
+type StatementLine struct {
+	Nominal sa.Nominal
+	Name    string
+	//Type is the account type name, e.g. BANK
+	Type string
+	//Depth is 1 for a top level section, e.g. Assets, 2 for its child accounts and so on
+	Depth   int
+	Balance int64
+	//Lines are the child accounts. They are empty if the statement has been collapsed to this depth
+	Lines []*StatementLine
+}
+
+//Statement is the common part of a BalanceSheet and ProfitAndLoss
+type Statement struct {
+	ChartName string
+	Crcy      string
+	//From and To are the dates of the journals included in the statement.
+	//A zero From includes all journals up to To, and a zero To is for the current values
+	From time.Time
+	To   time.Time
+	//Sections are the top level accounts of the statement
+	Sections []*StatementLine
+}
+
+//BalanceSheet lists the asset, liability and equity accounts of a chart.
+//Net profit, from the income and expense accounts, is carried into equity
+type BalanceSheet struct {
+	Statement
+	Assets      int64
+	Liabilities int64
+	//NetAssets is Assets less Liabilities
+	NetAssets int64
+	//NetProfit is included in Equity
+	NetProfit int64
+	Equity    int64
+}
+
+//ProfitAndLoss lists the income and expense accounts of a chart
+type ProfitAndLoss struct {
+	Statement
+	Income   int64
+	Expenses int64
+	//NetProfit is Income less Expenses
+	NetProfit int64
+}
+
+//NewBalanceSheet returns the balance sheet for a chart. depth limits the levels of accounts shown,
+//lower levels being collapsed into their parent. A depth of 0 shows all levels
+func NewBalanceSheet(chart *sa.Chart, depth int) (*BalanceSheet, error) {
+	bs := &BalanceSheet{
+		Statement: newStatement(chart),
+	}
+	types := merge(assetTypes, liabilityTypes, equityTypes)
+	if err := bs.addSections(chart, types, depth); err != nil {
+		return nil, err
+	}
+	pl, err := NewProfitAndLoss(chart, depth)
+	if err != nil {
+		return nil, err
+	}
+	bs.NetProfit = pl.NetProfit
+
+	var equity *StatementLine
+	for _, section := range bs.Sections {
+		switch {
+		case assetTypes[section.Type]:
+			bs.Assets += section.Balance
+		case liabilityTypes[section.Type]:
+			bs.Liabilities += section.Balance
+		default:
+			bs.Equity += section.Balance
+			equity = section
+		}
+	}
+
+	//carry net profit into equity
+	if equity == nil {
+		equity = &StatementLine{Name: "Equity", Type: "EQUITY", Depth: 1}
+		bs.Sections = append(bs.Sections, equity)
+	}
+	equity.Balance += bs.NetProfit
+	if depth == 0 || depth > 1 {
+		equity.Lines = append(equity.Lines, &StatementLine{
+			Name:    NetProfitName,
+			Depth:   2,
+			Balance: bs.NetProfit,
+		})
+	}
+	bs.Equity += bs.NetProfit
+	bs.NetAssets = bs.Assets - bs.Liabilities
+	return bs, nil
+}
+
+//NewBalanceSheetAsAt returns the balance sheet for the accountant's chart as it was at the given date
+func NewBalanceSheetAsAt(accountant *sa.Accountant, dt time.Time, depth int) (*BalanceSheet, error) {
+	return NewBalanceSheetAsAtContext(context.Background(), accountant, dt, depth)
+}
+
+//NewBalanceSheetAsAtContext returns the balance sheet for the accountant's chart as it was at the given date
+func NewBalanceSheetAsAtContext(ctx context.Context, accountant *sa.Accountant, dt time.Time, depth int) (*BalanceSheet, error) {
+	chart, err := accountant.FetchChartAsAtContext(ctx, dt)
+	if err != nil {
+		return nil, err
+	}
+	bs, err := NewBalanceSheet(chart, depth)
+	if err != nil {
+		return nil, err
+	}
+	bs.To = dt
+	return bs, nil
+}
+
+//Balanced returns true if net assets equal equity, including net profit
+func (bs *BalanceSheet) Balanced() bool {
+	return bs.NetAssets == bs.Equity
+}
+
+//NewProfitAndLoss returns the profit and loss statement for a chart. depth limits the levels of accounts shown,
+//lower levels being collapsed into their parent. A depth of 0 shows all levels
+func NewProfitAndLoss(chart *sa.Chart, depth int) (*ProfitAndLoss, error) {
+	pl := &ProfitAndLoss{
+		Statement: newStatement(chart),
+	}
+	if err := pl.addSections(chart, merge(incomeTypes, expenseTypes), depth); err != nil {
+		return nil, err
+	}
+	for _, section := range pl.Sections {
+		if incomeTypes[section.Type] {
+			pl.Income += section.Balance
+		} else {
+			pl.Expenses += section.Balance
+		}
+	}
+	pl.NetProfit = pl.Income - pl.Expenses
+	return pl, nil
+}
+
+//NewProfitAndLossBetween returns the profit and loss statement for the accountant's chart,
+//from the journals dated between from and to inclusive
+func NewProfitAndLossBetween(accountant *sa.Accountant, from, to time.Time, depth int) (*ProfitAndLoss, error) {
+	return NewProfitAndLossBetweenContext(context.Background(), accountant, from, to, depth)
+}
+
+//NewProfitAndLossBetweenContext returns the profit and loss statement for the accountant's chart,
+//from the journals dated between from and to inclusive
+func NewProfitAndLossBetweenContext(ctx context.Context, accountant *sa.Accountant, from, to time.Time, depth int) (*ProfitAndLoss, error) {
+	chart, err := accountant.FetchChartBetweenContext(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	pl, err := NewProfitAndLoss(chart, depth)
+	if err != nil {
+		return nil, err
+	}
+	pl.From = from
+	pl.To = to
+	return pl, nil
+}
+
+func newStatement(chart *sa.Chart) Statement {
+	return Statement{
+		ChartName: chart.Name(),
+		Crcy:      chart.Crcy(),
+		Sections:  make([]*StatementLine, 0),
+	}
+}
+
+//addSections adds the top level accounts of the given types, and their children, to the statement
+func (s *Statement) addSections(chart *sa.Chart, types map[string]bool, depth int) error {
+	if chart.Tree().GetValue() == nil {
+		return nil
+	}
+	sections, err := statementLines(chart.Tree(), types, 1, depth)
+	if err != nil {
+		return err
+	}
+	s.Sections = sections
+	return nil
+}
+
+//statementLines returns a line for the node if its type is one of types, else the lines for its children
+func statementLines(node tree.NodeIFace, types map[string]bool, depth, maxDepth int) ([]*StatementLine, error) {
+	ac := node.GetValue().(*sa.Account)
+	tpe := sa.GetValuedAccountTypes()[*ac.Type()]
+	if !types[tpe] {
+		lines := make([]*StatementLine, 0)
+		for _, child := range node.GetChildren() {
+			childLines, err := statementLines(child, types, depth, maxDepth)
+			if err != nil {
+				return nil, err
+			}
+			lines = append(lines, childLines...)
+		}
+		return lines, nil
+	}
+
+	balance, err := ac.Balance()
+	if err != nil {
+		return nil, err
+	}
+	line := &StatementLine{
+		Nominal: ac.Nominal(),
+		Name:    ac.Name(),
+		Type:    tpe,
+		Depth:   depth,
+		Balance: balance,
+		Lines:   make([]*StatementLine, 0),
+	}
+	if maxDepth > 0 && depth >= maxDepth {
+		return []*StatementLine{line}, nil
+	}
+	for _, child := range node.GetChildren() {
+		childLines, err := statementLines(child, types, depth+1, maxDepth)
+		if err != nil {
+			return nil, err
+		}
+		line.Lines = append(line.Lines, childLines...)
+	}
+	return []*StatementLine{line}, nil
+}
+
+func merge(typeSets ...map[string]bool) map[string]bool {
+	types := make(map[string]bool)
+	for _, set := range typeSets {
+		for k, v := range set {
+			types[k] = v
+		}
+	}
+	return types
+}
+
+//Write writes the balance sheet to w as a text table
+func (bs *BalanceSheet) Write(w io.Writer) error {
+	return bs.write(w, "Balance Sheet", []total{
+		{"Net Assets", bs.NetAssets},
+		{"Total Equity", bs.Equity},
+	})
+}
+
+//Write writes the profit and loss statement to w as a text table
+func (pl *ProfitAndLoss) Write(w io.Writer) error {
+	return pl.write(w, "Profit And Loss", []total{
+		{NetProfitName, pl.NetProfit},
+	})
+}
+
+//total is a named total written at the foot of a statement
+type total struct {
+	name  string
+	value int64
+}
+
+func (s *Statement) write(w io.Writer, title string, totals []total) error {
+	title += ": " + s.ChartName
+	if !s.From.IsZero() {
+		title += " from " + s.From.Format("2006-01-02")
+	}
+	if !s.To.IsZero() {
+		title += " to " + s.To.Format("2006-01-02")
+	}
+	if _, err := fmt.Fprintf(w, "%s (%s)\n", title, s.Crcy); err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	var writeLines func(lines []*StatementLine) error
+	writeLines = func(lines []*StatementLine) error {
+		for _, line := range lines {
+			indent := strings.Repeat("  ", line.Depth-1)
+			if _, err := fmt.Fprintf(tw, "%s\t%s%s\t%d\n", line.Nominal, indent, line.Name, line.Balance); err != nil {
+				return err
+			}
+			if err := writeLines(line.Lines); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := writeLines(s.Sections); err != nil {
+		return err
+	}
+	for _, t := range totals {
+		if _, err := fmt.Fprintf(tw, "\t%s\t%d\n", t.name, t.value); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,178 @@
+//go:build unit
+// +build unit
+
+package reports_test
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"bytes"
+	"github.com/chippyash/go-simple-accounts/reports"
+	"github.com/chippyash/go-simple-accounts/sa"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewBalanceSheet(t *testing.T) {
+	accountant := setupStatementTest(t)
+	chart, _ := accountant.FetchChart()
+
+	bs, err := reports.NewBalanceSheet(chart, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(8700), bs.Assets)
+	assert.Equal(t, int64(3000), bs.Liabilities)
+	assert.Equal(t, int64(5700), bs.NetAssets)
+	assert.Equal(t, int64(700), bs.NetProfit)
+	assert.Equal(t, int64(5700), bs.Equity)
+	assert.True(t, bs.Balanced())
+
+	assert.Equal(t, 3, len(bs.Sections))
+	assets := bs.Sections[0]
+	assert.Equal(t, sa.Nominal("1000"), assets.Nominal)
+	assert.Equal(t, 1, assets.Depth)
+	assert.Equal(t, int64(8700), assets.Balance)
+	//subtotals follow the nested accounts
+	current := assets.Lines[0]
+	assert.Equal(t, "Current Assets", current.Name)
+	assert.Equal(t, int64(5700), current.Balance)
+	bank := current.Lines[0].Lines[0]
+	assert.Equal(t, sa.Nominal("1210"), bank.Nominal)
+	assert.Equal(t, 4, bank.Depth)
+	assert.Equal(t, int64(5700), bank.Balance)
+
+	//net profit is carried into equity
+	equity := bs.Sections[2]
+	assert.Equal(t, "Equity", equity.Name)
+	assert.Equal(t, int64(5700), equity.Balance)
+	profit := equity.Lines[len(equity.Lines)-1]
+	assert.Equal(t, reports.NetProfitName, profit.Name)
+	assert.Equal(t, int64(700), profit.Balance)
+}
+
+func TestNewBalanceSheet_CollapsesToDepth(t *testing.T) {
+	accountant := setupStatementTest(t)
+	chart, _ := accountant.FetchChart()
+
+	bs, err := reports.NewBalanceSheet(chart, 1)
+	assert.NoError(t, err)
+	for _, section := range bs.Sections {
+		assert.Equal(t, 0, len(section.Lines))
+	}
+	assert.Equal(t, int64(5700), bs.Sections[2].Balance)
+	assert.True(t, bs.Balanced())
+
+	bs, err = reports.NewBalanceSheet(chart, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(bs.Sections[0].Lines))
+	for _, line := range bs.Sections[0].Lines {
+		assert.Equal(t, 0, len(line.Lines))
+	}
+	assert.Equal(t, int64(3000), bs.Sections[0].Lines[1].Balance)
+	assert.Equal(t, reports.NetProfitName, bs.Sections[2].Lines[1].Name)
+}
+
+func TestNewBalanceSheetAsAt(t *testing.T) {
+	accountant := setupStatementTest(t)
+	dt, _ := time.Parse(time.RFC3339, "2020-07-31T23:59:59Z")
+
+	bs, err := reports.NewBalanceSheetAsAt(accountant, dt, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, dt, bs.To)
+	assert.Equal(t, int64(6000), bs.Assets)
+	assert.Equal(t, int64(0), bs.Liabilities)
+	assert.Equal(t, int64(1000), bs.NetProfit)
+	assert.Equal(t, int64(6000), bs.Equity)
+	assert.True(t, bs.Balanced())
+}
+
+func TestNewProfitAndLoss(t *testing.T) {
+	accountant := setupStatementTest(t)
+	chart, _ := accountant.FetchChart()
+
+	pl, err := reports.NewProfitAndLoss(chart, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000), pl.Income)
+	assert.Equal(t, int64(300), pl.Expenses)
+	assert.Equal(t, int64(700), pl.NetProfit)
+	assert.Equal(t, 4, len(pl.Sections))
+	assert.Equal(t, sa.Nominal("4000"), pl.Sections[0].Nominal)
+	assert.Equal(t, sa.Nominal("6000"), pl.Sections[1].Nominal)
+	assert.Equal(t, sa.Nominal("7000"), pl.Sections[2].Nominal)
+	assert.Equal(t, sa.Nominal("8000"), pl.Sections[3].Nominal)
+	house := pl.Sections[1].Lines[0]
+	assert.Equal(t, "House", house.Name)
+	assert.Equal(t, int64(300), house.Balance)
+}
+
+func TestNewProfitAndLossBetween(t *testing.T) {
+	accountant := setupStatementTest(t)
+	from, _ := time.Parse(time.RFC3339, "2020-08-01T00:00:00Z")
+	to, _ := time.Parse(time.RFC3339, "2020-08-31T23:59:59Z")
+
+	pl, err := reports.NewProfitAndLossBetween(accountant, from, to, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, from, pl.From)
+	assert.Equal(t, to, pl.To)
+	assert.Equal(t, int64(0), pl.Income)
+	assert.Equal(t, int64(100), pl.Expenses)
+	assert.Equal(t, int64(-100), pl.NetProfit)
+}
+
+func TestStatement_Write(t *testing.T) {
+	accountant := setupStatementTest(t)
+	to, _ := time.Parse(time.RFC3339, "2020-08-31T23:59:59Z")
+	bs, _ := reports.NewBalanceSheetAsAt(accountant, to, 1)
+
+	var buf bytes.Buffer
+	assert.NoError(t, bs.Write(&buf))
+	out := strings.Split(buf.String(), "\n")
+	assert.Equal(t, "Balance Sheet: Test to 2020-08-31 (GBP)", out[0])
+	assert.Equal(t, []string{"1000", "Assets", "8900"}, strings.Fields(out[1]))
+	assert.Equal(t, []string{"Net", "Assets", "5900"}, strings.Fields(out[4]))
+	assert.Equal(t, []string{"Total", "Equity", "5900"}, strings.Fields(out[5]))
+
+	from, _ := time.Parse(time.RFC3339, "2020-08-01T00:00:00Z")
+	pl, _ := reports.NewProfitAndLossBetween(accountant, from, to, 0)
+	buf.Reset()
+	assert.NoError(t, pl.Write(&buf))
+	out = strings.Split(buf.String(), "\n")
+	assert.Equal(t, "Profit And Loss: Test from 2020-08-01 to 2020-08-31 (GBP)", out[0])
+	assert.True(t, strings.Contains(buf.String(), "      Gardener"))
+}
+
+//setupStatementTest returns an accountant for a chart with an opening balance and salary in July,
+//a gardener and a vehicle bought with a loan in August, and property tax in September
+func setupStatementTest(t *testing.T) *sa.Accountant {
+	accountant := sa.NewAccountant(sa.NewMemoryStore(), 0, "GBP")
+	def, err := sa.NewChartDefinition("../tests/_data/personal.xml")
+	assert.NoError(t, err)
+	_, err = accountant.CreateChart("Test", "GBP", def)
+	assert.NoError(t, err)
+
+	july, _ := time.Parse(time.RFC3339, "2020-07-15T12:00:00Z")
+	august := july.AddDate(0, 1, 0)
+	september := july.AddDate(0, 2, 0)
+	for _, txn := range []struct {
+		dt     time.Time
+		dr, cr sa.Nominal
+		amount int64
+	}{
+		{july, "1210", "3100", 5000},
+		{july, "1210", "4100", 1000},
+		{august, "6121", "1210", 100},
+		{august, "1700", "2200", 3000},
+		{september, "6140", "1210", 200},
+	} {
+		_, err = accountant.WriteTransactionWithDate(sa.NewSimpleTransactionBuilder(0, txn.dr, txn.cr, txn.amount).Build(), txn.dt)
+		assert.NoError(t, err)
+	}
+	return accountant
+}
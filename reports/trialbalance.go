@@ -0,0 +1,172 @@
+package reports
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"context"
+	"fmt"
+	"github.com/chippyash/go-hierarchy-tree/tree"
+	"github.com/chippyash/go-simple-accounts/sa"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+//TrialBalanceLine is the balance of a single account in a TrialBalance.
+//The balance is shown in either the Dr or the Cr column
+type TrialBalanceLine struct {
+	Nominal sa.Nominal
+	Name    string
+	//Type is the account type name, e.g. BANK
+	Type string
+	//DrTitle and CrTitle are the account type titles for the Dr and Cr columns, e.g. Increase and Decrease
+	DrTitle string
+	CrTitle string
+	Dr      int64
+	Cr      int64
+}
+
+//TrialBalance lists the balance of every leaf account in a chart, and the debit and credit totals.
+//A parent account that has had journals posted directly to it is also listed, with the value of those journals
+type TrialBalance struct {
+	ChartName string
+	Crcy      string
+	//AsAt is the date of the balances, or zero for the current balances
+	AsAt time.Time
+	//DrTitle and CrTitle are the captions for the Dr and Cr columns
+	DrTitle string
+	CrTitle string
+	Lines   []TrialBalanceLine
+	TotalDr int64
+	TotalCr int64
+}
+
+//NewTrialBalance returns the trial balance for a chart.
+//The root account, and any other REAL account, is not listed
+func NewTrialBalance(chart *sa.Chart) (*TrialBalance, error) {
+	drTitle, crTitle, err := sa.NewAcType().Dr().Titles()
+	if err != nil {
+		return nil, err
+	}
+	tb := &TrialBalance{
+		ChartName: chart.Name(),
+		Crcy:      chart.Crcy(),
+		DrTitle:   drTitle,
+		CrTitle:   crTitle,
+		Lines:     make([]TrialBalanceLine, 0),
+	}
+	if chart.Tree().GetValue() == nil {
+		return tb, nil
+	}
+	if err = tb.addLines(chart.Tree()); err != nil {
+		return nil, err
+	}
+	sort.Slice(tb.Lines, func(i, j int) bool {
+		return tb.Lines[i].Nominal < tb.Lines[j].Nominal
+	})
+	for _, line := range tb.Lines {
+		tb.TotalDr += line.Dr
+		tb.TotalCr += line.Cr
+	}
+	return tb, nil
+}
+
+//NewTrialBalanceAsAt returns the trial balance for the accountant's chart as it was at the given date
+func NewTrialBalanceAsAt(accountant *sa.Accountant, dt time.Time) (*TrialBalance, error) {
+	return NewTrialBalanceAsAtContext(context.Background(), accountant, dt)
+}
+
+//NewTrialBalanceAsAtContext returns the trial balance for the accountant's chart as it was at the given date
+func NewTrialBalanceAsAtContext(ctx context.Context, accountant *sa.Accountant, dt time.Time) (*TrialBalance, error) {
+	chart, err := accountant.FetchChartAsAtContext(ctx, dt)
+	if err != nil {
+		return nil, err
+	}
+	tb, err := NewTrialBalance(chart)
+	if err != nil {
+		return nil, err
+	}
+	tb.AsAt = dt
+	return tb, nil
+}
+
+//addLines adds a line for the node if it is a leaf, or has journals posted directly to it, and then for its children
+func (tb *TrialBalance) addLines(node tree.NodeIFace) error {
+	ac := node.GetValue().(*sa.Account)
+	acDr, acCr := ac.Dr(), ac.Cr()
+	for _, child := range node.GetChildren() {
+		acDr -= child.GetValue().(*sa.Account).Dr()
+		acCr -= child.GetValue().(*sa.Account).Cr()
+		if err := tb.addLines(child); err != nil {
+			return err
+		}
+	}
+	if *ac.Type() == *sa.NewAcType().Real() || (!node.IsLeaf() && acDr == 0 && acCr == 0) {
+		return nil
+	}
+
+	drTitle, crTitle, err := ac.Type().Titles()
+	if err != nil {
+		return err
+	}
+	line := TrialBalanceLine{
+		Nominal: ac.Nominal(),
+		Name:    ac.Name(),
+		Type:    sa.GetValuedAccountTypes()[*ac.Type()],
+		DrTitle: drTitle,
+		CrTitle: crTitle,
+	}
+	if acDr >= acCr {
+		line.Dr = acDr - acCr
+	} else {
+		line.Cr = acCr - acDr
+	}
+	tb.Lines = append(tb.Lines, line)
+	return nil
+}
+
+//Balanced returns true if the debit and credit totals are equal
+func (tb *TrialBalance) Balanced() bool {
+	return tb.TotalDr == tb.TotalCr
+}
+
+//Difference returns the debit total less the credit total. It is zero for a balanced trial balance
+func (tb *TrialBalance) Difference() int64 {
+	return tb.TotalDr - tb.TotalCr
+}
+
+//Write writes the trial balance to w as a text table
+func (tb *TrialBalance) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	title := "Trial Balance: " + tb.ChartName
+	if !tb.AsAt.IsZero() {
+		title += " as at " + tb.AsAt.Format("2006-01-02")
+	}
+	if _, err := fmt.Fprintf(w, "%s (%s)\n", title, tb.Crcy); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(tw, "Nominal\tName\tType\t%s\t%s\t\n", tb.DrTitle, tb.CrTitle); err != nil {
+		return err
+	}
+	for _, line := range tb.Lines {
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\t\n", line.Nominal, line.Name, line.Type, line.Dr, line.Cr); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(tw, "\tTotal\t\t%d\t%d\t\n", tb.TotalDr, tb.TotalCr); err != nil {
+		return err
+	}
+	if !tb.Balanced() {
+		if _, err := fmt.Fprintf(tw, "\tOut of balance\t\t%d\t\t\n", tb.Difference()); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,144 @@
+//go:build unit
+// +build unit
+
+package reports_test
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"bytes"
+	"github.com/chippyash/go-hierarchy-tree/tree"
+	"github.com/chippyash/go-simple-accounts/reports"
+	"github.com/chippyash/go-simple-accounts/sa"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewTrialBalance(t *testing.T) {
+	accountant := setupReportsTest(t)
+	chart, _ := accountant.FetchChart()
+
+	tb, err := reports.NewTrialBalance(chart)
+	assert.NoError(t, err)
+	assert.Equal(t, "Test", tb.ChartName)
+	assert.Equal(t, "GBP", tb.Crcy)
+	assert.True(t, tb.AsAt.IsZero())
+	assert.Equal(t, "Debit", tb.DrTitle)
+	assert.Equal(t, "Credit", tb.CrTitle)
+	assert.True(t, tb.Balanced())
+	assert.Equal(t, int64(0), tb.Difference())
+	assert.Equal(t, int64(1100), tb.TotalDr)
+	assert.Equal(t, int64(1100), tb.TotalCr)
+
+	lines := make(map[sa.Nominal]reports.TrialBalanceLine)
+	for _, line := range tb.Lines {
+		lines[line.Nominal] = line
+	}
+	//root and parent accounts are not listed
+	for _, nom := range []sa.Nominal{"0000", "0001", "1000", "1200", "6100"} {
+		_, ok := lines[nom]
+		assert.False(t, ok, "nominal %s should not be listed", nom)
+	}
+	bank := lines["1210"]
+	assert.Equal(t, "Current Accounts", bank.Name)
+	assert.Equal(t, "BANK", bank.Type)
+	assert.Equal(t, "Increase", bank.DrTitle)
+	assert.Equal(t, "Decrease", bank.CrTitle)
+	assert.Equal(t, int64(900), bank.Dr)
+	assert.Equal(t, int64(0), bank.Cr)
+	assert.Equal(t, int64(1000), lines["4100"].Cr)
+	assert.Equal(t, int64(100), lines["6121"].Dr)
+	//zero value leaf accounts are listed
+	assert.Equal(t, int64(0), lines["1220"].Dr)
+	assert.Equal(t, int64(0), lines["1220"].Cr)
+	//a parent account with journals posted directly to it is listed
+	assert.Equal(t, int64(100), lines["6120"].Cr)
+	assert.Equal(t, int64(100), lines["0002"].Dr)
+	//lines are in nominal order
+	for i := 1; i < len(tb.Lines); i++ {
+		assert.True(t, tb.Lines[i-1].Nominal < tb.Lines[i].Nominal)
+	}
+}
+
+func TestNewTrialBalanceAsAt(t *testing.T) {
+	accountant := setupReportsTest(t)
+	dt, _ := time.Parse(time.RFC3339, "2020-07-31T23:59:59Z")
+
+	tb, err := reports.NewTrialBalanceAsAt(accountant, dt)
+	assert.NoError(t, err)
+	assert.Equal(t, dt, tb.AsAt)
+	assert.True(t, tb.Balanced())
+	assert.Equal(t, int64(1000), tb.TotalDr)
+	for _, line := range tb.Lines {
+		if line.Nominal == "1210" {
+			assert.Equal(t, int64(1000), line.Dr)
+		}
+	}
+}
+
+func TestTrialBalance_FlagsImbalance(t *testing.T) {
+	root := tree.NewNode(
+		sa.NewAccount("0000", sa.NewAcType().Real(), "COA", 100, 90, 1),
+		&[]tree.NodeIFace{
+			tree.NewNode(sa.NewAccount("1000", sa.NewAcType().Asset(), "Assets", 100, 0, 1), nil),
+			tree.NewNode(sa.NewAccount("2000", sa.NewAcType().Liability(), "Liabilities", 0, 90, 1), nil),
+		},
+	)
+	tb, err := reports.NewTrialBalance(sa.NewChart(1, "Test", "GBP", root))
+	assert.NoError(t, err)
+	assert.False(t, tb.Balanced())
+	assert.Equal(t, int64(10), tb.Difference())
+
+	var buf bytes.Buffer
+	assert.NoError(t, tb.Write(&buf))
+	assert.True(t, strings.Contains(buf.String(), "Out of balance"))
+}
+
+func TestTrialBalance_Write(t *testing.T) {
+	accountant := setupReportsTest(t)
+	dt, _ := time.Parse(time.RFC3339, "2020-08-31T23:59:59Z")
+	tb, _ := reports.NewTrialBalanceAsAt(accountant, dt)
+
+	var buf bytes.Buffer
+	assert.NoError(t, tb.Write(&buf))
+	out := strings.Split(buf.String(), "\n")
+	assert.Equal(t, "Trial Balance: Test as at 2020-08-31 (GBP)", out[0])
+	assert.Equal(t, []string{"Nominal", "Name", "Type", "Debit", "Credit"}, strings.Fields(out[1]))
+	assert.Equal(t, []string{"1210", "Current", "Accounts", "BANK", "900", "0"}, strings.Fields(out[3]))
+	assert.Equal(t, []string{"Total", "1100", "1100"}, strings.Fields(out[len(out)-2]))
+	assert.False(t, strings.Contains(buf.String(), "Out of balance"))
+}
+
+//setupReportsTest returns an accountant for a chart with salary received in July, a gardener paid in August,
+//and an August journal posted directly to the Garden and Profit And Loss parent accounts
+func setupReportsTest(t *testing.T) *sa.Accountant {
+	accountant := sa.NewAccountant(sa.NewMemoryStore(), 0, "GBP")
+	def, err := sa.NewChartDefinition("../tests/_data/personal.xml")
+	assert.NoError(t, err)
+	_, err = accountant.CreateChart("Test", "GBP", def)
+	assert.NoError(t, err)
+
+	july, _ := time.Parse(time.RFC3339, "2020-07-15T12:00:00Z")
+	august := july.AddDate(0, 1, 0)
+	for _, txn := range []struct {
+		dt     time.Time
+		dr, cr sa.Nominal
+		amount int64
+	}{
+		{july, "1210", "4100", 1000},
+		{august, "6121", "1210", 100},
+		{august, "0002", "6120", 100},
+	} {
+		_, err = accountant.WriteTransactionWithDate(sa.NewSimpleTransactionBuilder(0, txn.dr, txn.cr, txn.amount).Build(), txn.dt)
+		assert.NoError(t, err)
+	}
+	return accountant
+}
@@ -9,54 +9,82 @@ package sa
  */
 
 import (
-	"database/sql"
-	"errors"
+	"context"
 	"fmt"
 	"github.com/chippyash/go-hierarchy-tree/tree"
-	_ "github.com/go-sql-driver/mysql"
 	"github.com/subchen/go-xmldom"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 //Accountant The main API interface to Simple Accounts
 type Accountant struct {
-	db      *sql.DB
+	store   Store
 	chartId uint64
 	crcy    string
+	//rates converts entries in another currency that do not have a rate
+	rates ExchangeRateProvider
+	//schemaMu guards schemaOk, which is set once the store schema version has been checked
+	schemaMu sync.Mutex
+	schemaOk bool
 }
 
-type ledger struct {
-	PrntId  uint64
-	Id      uint64
-	Nominal Nominal
-	Name    string
-	Tpe     string
-	AcDr    int64
-	AcCr    int64
-	ChartId uint64
-}
-type ledgerLines []ledger
-
-//NewAccountant returns a new Accountant
-func NewAccountant(adapter *sql.DB, chartId uint64, crcy string) *Accountant {
+//NewAccountant returns a new Accountant using the given storage backend.
+//The Accountant refuses to operate, returning ErrSchemaVersion, unless the store schema is at SchemaVersion
+func NewAccountant(store Store, chartId uint64, crcy string) *Accountant {
 	return &Accountant{
-		db:      adapter,
+		store:   store,
 		chartId: chartId,
 		crcy:    crcy,
 	}
 }
 
+//checkSchema refuses to use a store whose schema version is not the one this library works with.
+//The check is made on the first use of the Accountant, and repeated until it succeeds
+func (a *Accountant) checkSchema(ctx context.Context) error {
+	a.schemaMu.Lock()
+	defer a.schemaMu.Unlock()
+	if a.schemaOk {
+		return nil
+	}
+	version, dirty, err := a.store.SchemaVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrSchemaVersion, err)
+	}
+	if dirty {
+		return ErrDirtySchema
+	}
+	if version != SchemaVersion {
+		return fmt.Errorf("%w: database is at version %d, expected %d", ErrSchemaVersion, version, SchemaVersion)
+	}
+	a.schemaOk = true
+	return nil
+}
+
 //CreateChart creates a new chart of accounts from a COA definition file
 func (a *Accountant) CreateChart(chartName, crcy string, def *ChartDefinition) (uint64, error) {
+	return a.CreateChartContext(context.Background(), chartName, crcy, def)
+}
+
+//CreateChartContext creates a new chart of accounts from a COA definition file.
+//An empty currency is the Accountant's currency. ErrCurrencyMismatch is returned if the currencies differ
+func (a *Accountant) CreateChartContext(ctx context.Context, chartName, crcy string, def *ChartDefinition) (uint64, error) {
+	return a.CreateChartWithInfoContext(ctx, ChartInfo{Name: chartName, Crcy: crcy}, def)
+}
+
+//createChart creates the chart and its ledgers from a COA definition file
+func (a *Accountant) createChart(ctx context.Context, chartName string, def *ChartDefinition) (uint64, error) {
+	if err := a.checkSchema(ctx); err != nil {
+		return 0, err
+	}
 	dom, err := def.GetDefinition()
 	if err != nil {
 		return 0, err
 	}
 
-	chart := NewChart(0, chartName, crcy, nil)
-	chartId, err := a.storeChart(chart)
+	chartId, err := a.store.CreateChart(ctx, chartName)
 	if err != nil {
 		return 0, err
 	}
@@ -69,7 +97,7 @@ func (a *Accountant) CreateChart(chartName, crcy string, def *ChartDefinition) (
 		return 0, err
 	}
 
-	errV := treeRoot.Accept(NewNodeSaver(a.db))
+	errV := treeRoot.Accept(NewNodeSaver(ctx, a.store))
 	if errV != nil {
 		a.chartId = chartId
 		return chartId, errV.(error)
@@ -111,64 +139,94 @@ func buildTreeFromXml(tre tree.NodeIFace, node *xmldom.Node, chartId uint64) err
 	return nil
 }
 
-func (a *Accountant) storeChart(chart *Chart) (uint64, error) {
-	res, err := a.db.Query("select sa_fu_add_chart(?) as lastId", chart.Name())
-	if err != nil {
-		return 0, err
+//FetchChart fetches a chart from storage
+func (a *Accountant) FetchChart() (*Chart, error) {
+	return a.FetchChartContext(context.Background())
+}
+
+//FetchChartContext fetches a chart from storage
+func (a *Accountant) FetchChartContext(ctx context.Context) (*Chart, error) {
+	if a.chartId == 0 {
+		return nil, ErrNoChartId
 	}
-	if res.Err() != nil {
-		return 0, res.Err()
+	if err := a.checkSchema(ctx); err != nil {
+		return nil, err
 	}
-	var lastId int64 = 0
-	defer res.Close()
-	if res.Next() {
-		err = res.Scan(&lastId)
-		return uint64(lastId), err
+
+	ledgers, err := a.store.FetchLedgers(ctx, a.chartId)
+	if err != nil {
+		return nil, err
 	}
+	return a.chartFromLedgers(ctx, ledgers)
+}
 
-	return 0, nil
+//FetchChartAsAt fetches a chart from storage with the account values as they were at the given date.
+//The values are the totals of the journals dated on or before dt
+func (a *Accountant) FetchChartAsAt(dt time.Time) (*Chart, error) {
+	return a.FetchChartAsAtContext(context.Background(), dt)
 }
 
-//FetchChart fetches a chart from storage
-func (a *Accountant) FetchChart() (*Chart, error) {
+//FetchChartAsAtContext fetches a chart from storage with the account values as they were at the given date
+func (a *Accountant) FetchChartAsAtContext(ctx context.Context, dt time.Time) (*Chart, error) {
+	return a.FetchChartBetweenContext(ctx, time.Time{}, dt)
+}
+
+//FetchChartBetween fetches a chart from storage with the account values set from the journals dated
+//between from and to inclusive. A zero from includes all journals up to to
+func (a *Accountant) FetchChartBetween(from, to time.Time) (*Chart, error) {
+	return a.FetchChartBetweenContext(context.Background(), from, to)
+}
+
+//FetchChartBetweenContext fetches a chart from storage with the account values set from the journals dated
+//between from and to inclusive
+func (a *Accountant) FetchChartBetweenContext(ctx context.Context, from, to time.Time) (*Chart, error) {
 	if a.chartId == 0 {
 		return nil, ErrNoChartId
 	}
+	if err := a.checkSchema(ctx); err != nil {
+		return nil, err
+	}
 
-	//retrieve the ledgers
-	res, err := a.db.Query("call sa_sp_get_tree(?)", a.chartId)
+	ledgers, err := a.store.FetchLedgers(ctx, a.chartId)
 	if err != nil {
 		return nil, err
 	}
-	if res.Err() != nil {
-		return nil, res.Err()
+	totals, err := a.store.FetchLedgerTotals(ctx, a.chartId, from, to)
+	if err != nil {
+		return nil, err
 	}
+	return a.chartFromLedgers(ctx, ledgers.withTotals(totals))
+}
 
-	ledgers := make(ledgerLines, 0)
-	defer res.Close()
-	for res.Next() {
-		l := ledger{}
-		err = res.Scan(&l.PrntId, &l.Id, &l.Nominal, &l.Name, &l.Tpe, &l.AcDr, &l.AcCr, &l.ChartId)
-		if err != nil {
-			return nil, err
-		}
-		ledgers = append(ledgers, l)
-	}
+//BalanceAsAt returns the balance of an account as it was at the given date
+func (a *Accountant) BalanceAsAt(nominal Nominal, dt time.Time) (int64, error) {
+	return a.BalanceAsAtContext(context.Background(), nominal, dt)
+}
 
-	//retrieve the chart name
-	res2, err := a.db.Query("select name from sa_coa where id = ?", a.chartId)
+//BalanceAsAtContext returns the balance of an account as it was at the given date
+func (a *Accountant) BalanceAsAtContext(ctx context.Context, nominal Nominal, dt time.Time) (int64, error) {
+	chart, err := a.FetchChartAsAtContext(ctx, dt)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	if res.Err() != nil {
-		return nil, res.Err()
+	ac := chart.GetAccount(nominal)
+	if ac == nil {
+		return 0, ErrBadNominal
 	}
-	var chartName string
-	defer res2.Close()
-	if !res2.Next() {
-		return nil, ErrNoChartName
+	return ac.Balance()
+}
+
+//chartFromLedgers builds the chart tree from its stored ledgers
+func (a *Accountant) chartFromLedgers(ctx context.Context, ledgers Ledgers) (*Chart, error) {
+	if len(ledgers) == 0 {
+		return nil, ErrNoChartLedgers
 	}
-	err = res2.Scan(&chartName)
+
+	info, err := a.store.FetchChartInfo(ctx, a.chartId)
+	if err != nil {
+		return nil, err
+	}
+	crcy, err := a.chartCrcy(info)
 	if err != nil {
 		return nil, err
 	}
@@ -197,11 +255,11 @@ func (a *Accountant) FetchChart() (*Chart, error) {
 		return nil, err
 	}
 
-	return NewChart(a.chartId, chartName, a.crcy, root), nil
+	return NewChart(a.chartId, info.Name, crcy, root).withInfo(info), nil
 }
 
-func buildTreeFromDb(node tree.NodeIFace, ledgers ledgerLines, prntId uint64) (tree.NodeIFace, error) {
-	var childAccounts = make(ledgerLines, 0)
+func buildTreeFromDb(node tree.NodeIFace, ledgers Ledgers, prntId uint64) (tree.NodeIFace, error) {
+	var childAccounts = make(Ledgers, 0)
 	for _, line := range ledgers {
 		if line.PrntId == prntId {
 			childAccounts = append(childAccounts, line)
@@ -235,226 +293,286 @@ func buildTreeFromDb(node tree.NodeIFace, ledgers ledgerLines, prntId uint64) (t
 
 //WriteTransaction writes a transaction with default datetime of Now()
 func (a *Accountant) WriteTransaction(txn *SplitTransaction) (uint64, error) {
-	return a.WriteTransactionWithDate(txn, time.Now())
+	return a.WriteTransactionContext(context.Background(), txn)
+}
+
+//WriteTransactionContext writes a transaction with default datetime of Now()
+func (a *Accountant) WriteTransactionContext(ctx context.Context, txn *SplitTransaction) (uint64, error) {
+	return a.WriteTransactionWithDateContext(ctx, txn, time.Now())
 }
 
 //WriteTransactionWithDate writes a transaction with user supplied datetime
 func (a *Accountant) WriteTransactionWithDate(txn *SplitTransaction, dt time.Time) (uint64, error) {
+	return a.WriteTransactionWithDateContext(context.Background(), txn, dt)
+}
+
+//WriteTransactionWithDateContext writes a transaction with user supplied datetime
+func (a *Accountant) WriteTransactionWithDateContext(ctx context.Context, txn *SplitTransaction, dt time.Time) (uint64, error) {
 	if a.chartId == 0 {
 		return 0, ErrNoChartId
 	}
+	if err := a.checkSchema(ctx); err != nil {
+		return 0, err
+	}
 
+	txn, err := a.convertEntries(ctx, txn, dt)
+	if err != nil {
+		return 0, err
+	}
 	//validate transaction balance
 	if !txn.CheckBalance() {
 		return 0, ErrUnbalancedTransaction
 	}
+	if !txn.validCurrencies() {
+		return 0, ErrBadEntryCurrency
+	}
+	if err := a.checkPeriod(ctx, dt); err != nil {
+		return 0, err
+	}
 
-	stmt, err := a.db.Prepare("select sa_fu_add_txn(?, ?, ?, ?, ?, ?, ?, ?) as txnId")
-	if err != nil {
+	return a.store.WriteJournal(ctx, a.chartId, txn, dt)
+}
+
+//WriteTransactionOnce writes a transaction idempotently, using its src and ref as a key.
+//If a journal with the same src and ref already exists in the chart, its id is returned and nothing is written.
+//A *JournalConflictError is returned if the existing journal has different entries.
+//The note and date are not compared, so a retried write with a new date returns the original journal
+func (a *Accountant) WriteTransactionOnce(txn *SplitTransaction, dt time.Time) (uint64, error) {
+	return a.WriteTransactionOnceContext(context.Background(), txn, dt)
+}
+
+//WriteTransactionOnceContext writes a transaction idempotently, using its src and ref as a key
+func (a *Accountant) WriteTransactionOnceContext(ctx context.Context, txn *SplitTransaction, dt time.Time) (uint64, error) {
+	if a.chartId == 0 {
+		return 0, ErrNoChartId
+	}
+	if err := a.checkSchema(ctx); err != nil {
 		return 0, err
 	}
-	entryLen := len(txn.Entries())
-	var nominals = make([]string, entryLen)
-	var amounts = make([]string, entryLen)
-	var tpes = make([]string, entryLen)
-	acTypes := GetValuedAccountTypes()
-	for i, tx := range txn.Entries() {
-		nominals[i] = tx.Id().String()
-		amounts[i] = fmt.Sprintf("%d", tx.Amount())
-		tpes[i] = acTypes[*tx.Type()]
-	}
-	res, err := stmt.Query(
-		a.chartId,
-		txn.Note(),
-		dt,
-		txn.Src(),
-		txn.Ref(),
-		strings.Join(nominals, ","),
-		strings.Join(amounts, ","),
-		strings.Join(tpes, ","),
-	)
+	txn, err := a.convertEntries(ctx, txn, dt)
 	if err != nil {
 		return 0, err
 	}
-	if res.Err() != nil {
-		return 0, res.Err()
+	if !txn.CheckBalance() {
+		return 0, ErrUnbalancedTransaction
 	}
-	if !res.Next() {
-		return 0, ErrNoJrnId
+	if !txn.validCurrencies() {
+		return 0, ErrBadEntryCurrency
 	}
-	defer stmt.Close()
-	var jrnId uint64
-	err = res.Scan(&jrnId)
-	if err != nil {
-		return 0, err
+	if txn.Src() == "" || txn.Ref() == 0 {
+		return 0, ErrNoJournalKey
 	}
 
-	return jrnId, nil
+	filter := JournalFilter{Src: txn.Src(), Ref: txn.Ref(), Order: JournalOrderId, Limit: 1}
+	for attempt := 0; ; attempt++ {
+		existing, err := a.store.QueryJournals(ctx, a.chartId, filter)
+		if err != nil {
+			return 0, err
+		}
+		if len(existing) > 0 {
+			if !sameEntries(existing[0].Entries(), txn.Entries()) {
+				return 0, &JournalConflictError{Src: txn.Src(), Ref: txn.Ref(), JrnId: existing[0].Id()}
+			}
+			return existing[0].Id(), nil
+		}
+		if err = a.checkPeriod(ctx, dt); err != nil {
+			return 0, err
+		}
+		jrnId, err := a.store.WriteKeyedJournal(ctx, a.chartId, txn, dt)
+		//a concurrent writer stored the same key first, so compare with its journal
+		if err == ErrDuplicateJournal && attempt == 0 {
+			continue
+		}
+		return jrnId, err
+	}
+}
+
+//sameEntries returns true if both sets of entries debit and credit the same amounts to the same accounts
+func sameEntries(a, b Entries) bool {
+	totals := make(map[Nominal][2]int64)
+	add := func(entries Entries, sign int64) {
+		drAc := *NewAcType().Dr()
+		for _, entry := range entries {
+			t := totals[*entry.Id()]
+			if *entry.Type()&drAc == drAc {
+				t[0] += sign * entry.Amount()
+			} else {
+				t[1] += sign * entry.Amount()
+			}
+			totals[*entry.Id()] = t
+		}
+	}
+	add(a, 1)
+	add(b, -1)
+	for _, t := range totals {
+		if t != [2]int64{} {
+			return false
+		}
+	}
+	return true
 }
 
 //FetchTransaction retrieves a journal transaction identified by its journal id
 func (a *Accountant) FetchTransaction(jrnId uint64) (*SplitTransaction, error) {
+	return a.FetchTransactionContext(context.Background(), jrnId)
+}
+
+//FetchTransactionContext retrieves a journal transaction identified by its journal id
+func (a *Accountant) FetchTransactionContext(ctx context.Context, jrnId uint64) (*SplitTransaction, error) {
 	if a.chartId == 0 {
 		return nil, ErrNoChartId
 	}
-	//the journal
-	res, err := a.db.Query("select note, date, src, ref from sa_journal where id = ? and chartId = ?", jrnId, a.chartId)
-	if err != nil {
+	if err := a.checkSchema(ctx); err != nil {
 		return nil, err
 	}
-	if res.Err() != nil {
-		return nil, res.Err()
-	}
-	var note string
-	var dt time.Time
-	var src string
-	var ref uint64
-	defer res.Close()
-	if !res.Next() {
-		return nil, errors.New("cannot retrieve journal")
+	return a.store.FetchJournal(ctx, a.chartId, jrnId)
+}
+
+//FetchAccountJournals returns journal entries for an account
+//The returned Set is a Set of SplitTransactions with only the entries for
+//the required Account.  They will therefore be unbalanced.
+func (a *Accountant) FetchAccountJournals(nominal Nominal) ([]*SplitTransaction, error) {
+	return a.FetchAccountJournalsContext(context.Background(), nominal)
+}
+
+//FetchAccountJournalsContext returns journal entries for an account
+func (a *Accountant) FetchAccountJournalsContext(ctx context.Context, nominal Nominal) ([]*SplitTransaction, error) {
+	if a.chartId == 0 {
+		return nil, ErrNoChartId
 	}
-	err = res.Scan(&note, &dt, &src, &ref)
-	if err != nil {
+	if err := a.checkSchema(ctx); err != nil {
 		return nil, err
 	}
-	journal := NewSplitTransactionBuilder(jrnId).
-		WithDate(dt).
-		WithNote(note).
-		WithReference(ref).
-		WithSource(src)
+	return a.store.FetchAccountJournals(ctx, a.chartId, nominal)
+}
 
-	//journal entries
-	res2, err := a.db.Query("select id, nominal, acDr, acCr from sa_journal_entry where jrnId = ?", jrnId)
-	if err != nil {
-		return nil, err
-	}
-	if res2.Err() != nil {
-		return nil, res2.Err()
+//FindTransactionsBySource returns the journals with the external source and reference, in date order.
+//A zero ref finds the journals for the source with any reference
+func (a *Accountant) FindTransactionsBySource(src string, ref uint64) ([]*SplitTransaction, error) {
+	return a.FindTransactionsBySourceContext(context.Background(), src, ref)
+}
+
+//FindTransactionsBySourceContext returns the journals with the external source and reference, in date order
+func (a *Accountant) FindTransactionsBySourceContext(ctx context.Context, src string, ref uint64) ([]*SplitTransaction, error) {
+	return a.findTransactions(ctx, JournalFilter{Src: src, Ref: ref})
+}
+
+//FindTransactionsBySourceBetween returns the journals with the external source, dated between from and to
+//inclusive, in date order. A zero from or to leaves that end of the range open
+func (a *Accountant) FindTransactionsBySourceBetween(src string, from, to time.Time) ([]*SplitTransaction, error) {
+	return a.FindTransactionsBySourceBetweenContext(context.Background(), src, from, to)
+}
+
+//FindTransactionsBySourceBetweenContext returns the journals with the external source, dated between from and to
+func (a *Accountant) FindTransactionsBySourceBetweenContext(ctx context.Context, src string, from, to time.Time) ([]*SplitTransaction, error) {
+	return a.findTransactions(ctx, JournalFilter{Src: src, From: from, To: to})
+}
+
+//findTransactions returns all the journals selected by the filter, with all their entries
+func (a *Accountant) findTransactions(ctx context.Context, filter JournalFilter) ([]*SplitTransaction, error) {
+	if a.chartId == 0 {
+		return nil, ErrNoChartId
 	}
-	var id uint64
-	var nominal Nominal
-	var acDr, acCr int64
-	defer res2.Close()
-	for res.Next() {
-		err = res.Scan(&id, &nominal, &acDr, &acCr)
-		if err != nil {
-			return nil, err
-		}
-		var amount int64
-		var acType *AccountType
-		if acDr == 0 {
-			amount = acCr
-			acType = NewAcType().Cr()
-		} else {
-			amount = acDr
-			acType = NewAcType().Dr()
-		}
-		journal = journal.WithEntry(*NewEntry(nominal, amount, *acType))
+	if err := a.checkSchema(ctx); err != nil {
+		return nil, err
 	}
+	return a.store.QueryJournals(ctx, a.chartId, filter)
+}
 
-	return journal.Build(), nil
+//ReverseTransaction posts a journal that mirrors the journal jrnId, with every debit and credit swapped,
+//and marks jrnId as void. The reversing journal is linked to jrnId and its id is returned.
+//A void journal, or a reversing journal, cannot be reversed
+func (a *Accountant) ReverseTransaction(jrnId uint64, dt time.Time, note string) (uint64, error) {
+	return a.ReverseTransactionContext(context.Background(), jrnId, dt, note)
 }
 
-//FetchAccountJournals returns journal entries for an account
-//The returned Set is a Set of SplitTransactions with only the entries for
-//the required Account.  They will therefore be unbalanced.
-func (a *Accountant) FetchAccountJournals(nominal Nominal) ([]*SplitTransaction, error) {
-	response := make([]*SplitTransaction, 0)
+//ReverseTransactionContext posts a journal that mirrors the journal jrnId, with every debit and credit swapped,
+//and marks jrnId as void
+func (a *Accountant) ReverseTransactionContext(ctx context.Context, jrnId uint64, dt time.Time, note string) (uint64, error) {
 	if a.chartId == 0 {
-		return nil, ErrNoChartId
+		return 0, ErrNoChartId
 	}
-	complexSelect := `
-select j.id, j.note, j.date, j.src, j.ref, e.acDr, e.acCr
-from sa_journal as j
-join sa_journal_entry as e
-on j.id = e.jrnid
-where e.nominal = ? and j.chartId = ?
-`
-	res, err := a.db.Query(complexSelect, nominal.String(), a.chartId)
+	if err := a.checkSchema(ctx); err != nil {
+		return 0, err
+	}
+	original, err := a.store.FetchJournal(ctx, a.chartId, jrnId)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	if res.Err() != nil {
-		return nil, res.Err()
+	if original.IsVoid() {
+		return 0, ErrJournalVoid
 	}
-	var id, ref uint64
-	var acDr, acCr int64
-	var note, src string
-	var date time.Time
-	for res.Next() {
-		err = res.Scan(&id, &note, &date, &src, &ref, &acDr, &acCr)
-		if err != nil {
-			return nil, err
-		}
-		var amount int64
-		var acType *AccountType
-		if acDr == 0 {
-			amount = acCr
-			acType = NewAcType().Cr()
-		} else {
-			amount = acDr
-			acType = NewAcType().Dr()
+	if original.ReversalOf() != 0 {
+		return 0, ErrReversalJournal
+	}
+	if err = a.checkPeriod(ctx, dt); err != nil {
+		return 0, err
+	}
+
+	drAc := *NewAcType().Dr()
+	crAc := *NewAcType().Cr()
+	reversal := NewSplitTransactionBuilder(0).
+		WithDate(dt).
+		WithNote(note).
+		WithReversalOf(jrnId)
+	for _, entry := range original.Entries() {
+		tpe := drAc
+		if *entry.Type()&drAc == drAc {
+			tpe = crAc
 		}
-		entry := NewEntry(nominal, amount, *acType)
-		journal := NewSplitTransactionBuilder(id).
-			WithNote(note).
-			WithDate(date).
-			WithSource(src).
-			WithReference(ref).
-			WithEntry(*entry).
-			Build()
-		response = append(response, journal)
+		reversal = reversal.WithEntry(*entry.withType(tpe))
 	}
 
-	return response, nil
+	return a.store.WriteJournal(ctx, a.chartId, reversal.Build(), dt)
 }
 
 //AddAccount adds an account (ledger) to the chart.
 //Error returned if parent doesn't exist, or you try to add a second root account
 func (a *Accountant) AddAccount(nominal Nominal, tpe *AccountType, name string, prnt *Nominal) error {
-	var prntNominal string
-	if prnt == nil {
-		prntNominal = ""
-	} else {
-		prntNominal = prnt.String()
-	}
-	acTypes := GetValuedAccountTypes()
-	res, err := a.db.Query("call sa_sp_add_ledger(?, ?, ?, ?, ?)",
-		a.chartId,
-		nominal.String(),
-		acTypes[*tpe],
-		name,
-		prntNominal,
-	)
-	if err != nil {
+	return a.AddAccountContext(context.Background(), nominal, tpe, name, prnt)
+}
+
+//AddAccountContext adds an account (ledger) to the chart.
+func (a *Accountant) AddAccountContext(ctx context.Context, nominal Nominal, tpe *AccountType, name string, prnt *Nominal) error {
+	if a.chartId == 0 {
+		return ErrNoChartId
+	}
+	if err := a.checkSchema(ctx); err != nil {
 		return err
 	}
-	if res.Err() != nil {
-		return res.Err()
+	var prntNominal Nominal
+	if prnt != nil {
+		prntNominal = *prnt
 	}
-	return nil
+	return a.store.AddLedger(ctx, a.chartId, nominal, tpe, name, prntNominal)
 }
 
 //DelAccount deletes an account (ledger) and all its child accounts.
 //Error returned if the account has non zero debit or credit amounts
 func (a *Accountant) DelAccount(nominal Nominal) error {
-	res, err := a.db.Query("call sa_sp_del_ledger(?, ?)",
-		a.chartId,
-		nominal.String(),
-	)
-	if err != nil {
-		return err
+	return a.DelAccountContext(context.Background(), nominal)
+}
+
+//DelAccountContext deletes an account (ledger) and all its child accounts.
+func (a *Accountant) DelAccountContext(ctx context.Context, nominal Nominal) error {
+	if a.chartId == 0 {
+		return ErrNoChartId
 	}
-	if res.Err() != nil {
-		return res.Err()
+	if err := a.checkSchema(ctx); err != nil {
+		return err
 	}
-	return nil
+	return a.store.DelLedger(ctx, a.chartId, nominal)
 }
 
 //NextNominal returns the next nominal in sequence of child accounts of prnt.
 //starter is given and returned if the prnt does not have child accounts
 func (a *Accountant) NextNominal(prnt, starter Nominal) (*Nominal, error) {
-	chart, err := a.FetchChart()
+	return a.NextNominalContext(context.Background(), prnt, starter)
+}
+
+//NextNominalContext returns the next nominal in sequence of child accounts of prnt.
+func (a *Accountant) NextNominalContext(ctx context.Context, prnt, starter Nominal) (*Nominal, error) {
+	chart, err := a.FetchChartContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -16,6 +16,20 @@ import (
 var accountant *sa.Accountant
 var db *sql.DB
 
+//the MySQL store, in a database that is rebuilt for each test
+func init() {
+	testAccountants["mysql"] = func(t *testing.T) *sa.Accountant {
+		setupAccountantTest(t)
+		assert.NoError(t, sa.MigrateTo(db, 0))
+		assert.NoError(t, sa.Migrate(db))
+		def, err := sa.NewChartDefinition("../tests/_data/personal.xml")
+		assert.NoError(t, err)
+		_, err = accountant.CreateChart("Test", "GBP", def)
+		assert.NoError(t, err)
+		return accountant
+	}
+}
+
 func TestAccountant_CreateChart(t *testing.T) {
 	setupAccountantTest(t)
 	def, err := sa.NewChartDefinition("../tests/_data/personal.xml")
@@ -30,7 +44,7 @@ func TestAccountant_FetchChart(t *testing.T) {
 	setupAccountantTest(t)
 	def, _ := sa.NewChartDefinition("../tests/_data/personal.xml")
 	lastId, _ := accountant.CreateChart("Test", "GBP", def)
-	accountant = sa.NewAccountant(db, lastId, "GBP")
+	accountant = sa.NewAccountant(sa.NewMysqlStore(db), lastId, "GBP")
 	chart, err := accountant.FetchChart()
 	assert.NoError(t, err)
 	assert.Equal(t, 5, chart.Tree().GetHeight())
@@ -107,6 +121,153 @@ func TestAccountant_FetchAccountJournals(t *testing.T) {
 	assert.Equal(t, 2, len(entries))
 	assert.Equal(t, 1, len(entries[0].Entries()))
 	assert.Equal(t, 1, len(entries[1].Entries()))
+	assert.Less(t, entries[0].Id(), entries[1].Id())
+
+	teardownAccountantTest(t)
+}
+
+func TestAccountant_ReverseTransaction(t *testing.T) {
+	setupAccountantTest(t)
+	def, _ := sa.NewChartDefinition("../tests/_data/personal.xml")
+	_, _ = accountant.CreateChart("Test", "GBP", def)
+	dt, _ := time.Parse(time.RFC3339, "2020-08-05T14:36:00+01:00")
+	txn := sa.NewSimpleTransactionBuilder(0, "1210", "4100", 100).
+		WithDate(dt).
+		Build()
+	jrnId, _ := accountant.WriteTransactionWithDate(txn, dt)
+
+	revId, err := accountant.ReverseTransaction(jrnId, dt, "reversal")
+	assert.NoError(t, err)
+	original, err := accountant.FetchTransaction(jrnId)
+	assert.NoError(t, err)
+	assert.True(t, original.IsVoid())
+	assert.Equal(t, revId, original.ReversedBy())
+	reversal, err := accountant.FetchTransaction(revId)
+	assert.NoError(t, err)
+	assert.Equal(t, jrnId, reversal.ReversalOf())
+	assert.Equal(t, "4100", reversal.GetDrAc()[0].String())
+
+	chart, _ := accountant.FetchChart()
+	assert.Equal(t, int64(100), chart.GetAccount("1210").Dr())
+	assert.Equal(t, int64(100), chart.GetAccount("1210").Cr())
+
+	_, err = accountant.ReverseTransaction(jrnId, dt, "")
+	assert.ErrorIs(t, err, sa.ErrJournalVoid)
+
+	teardownAccountantTest(t)
+}
+
+func TestAccountant_BalanceAsAt(t *testing.T) {
+	setupAccountantTest(t)
+	def, _ := sa.NewChartDefinition("../tests/_data/personal.xml")
+	_, _ = accountant.CreateChart("Test", "GBP", def)
+	dt, _ := time.Parse(time.RFC3339, "2020-07-31T12:00:00Z")
+	txn := sa.NewSimpleTransactionBuilder(0, "1210", "4100", 100).Build()
+	_, _ = accountant.WriteTransactionWithDate(txn, dt)
+	txn = sa.NewSimpleTransactionBuilder(0, "1210", "4100", 50).Build()
+	_, _ = accountant.WriteTransactionWithDate(txn, dt.AddDate(0, 1, 0))
+
+	balance, err := accountant.BalanceAsAt("1210", dt)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), balance)
+	chart, err := accountant.FetchChartAsAt(dt.AddDate(0, 1, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(150), chart.GetAccount("1000").Dr())
+
+	teardownAccountantTest(t)
+}
+
+func TestAccountant_QueryJournals(t *testing.T) {
+	setupAccountantTest(t)
+	def, _ := sa.NewChartDefinition("../tests/_data/personal.xml")
+	_, _ = accountant.CreateChart("Test", "GBP", def)
+	dt, _ := time.Parse(time.RFC3339, "2020-07-31T12:00:00Z")
+	for i, nominal := range []sa.Nominal{"4100", "4200", "4100"} {
+		txn := sa.NewSimpleTransactionBuilder(0, "1210", nominal, 100).WithSource("PAY").WithNote("Salary").Build()
+		_, _ = accountant.WriteTransactionWithDate(txn, dt.AddDate(0, 0, -i))
+	}
+
+	query := sa.NewJournalQuery().ForAccount("4100").WithNoteContaining("salary").Limit(1)
+	page, err := accountant.QueryJournals(query)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(page.Journals))
+	assert.Equal(t, dt.AddDate(0, 0, -2), page.Journals[0].Date())
+	assert.Equal(t, 2, len(page.Journals[0].Entries()))
+	assert.NotEqual(t, "", page.Next)
+
+	page, err = accountant.QueryJournals(query.After(page.Next))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(page.Journals))
+	assert.Equal(t, dt, page.Journals[0].Date())
+	assert.Equal(t, "", page.Next)
+
+	it, err := accountant.Journals(sa.NewJournalQuery().ForAccount("1210"))
+	assert.NoError(t, err)
+	count := 0
+	for it.Next() {
+		assert.Equal(t, 2, len(it.Journal().Entries()))
+		count++
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, 3, count)
+
+	teardownAccountantTest(t)
+}
+
+func TestAccountant_WriteTransactionOnce(t *testing.T) {
+	setupAccountantTest(t)
+	def, _ := sa.NewChartDefinition("../tests/_data/personal.xml")
+	_, _ = accountant.CreateChart("Test", "GBP", def)
+	dt, _ := time.Parse(time.RFC3339, "2020-07-31T12:00:00Z")
+
+	txn := sa.NewSimpleTransactionBuilder(0, "1210", "4100", 100).WithSource("INV").WithReference(42).Build()
+	jrnId, err := accountant.WriteTransactionOnce(txn, dt)
+	assert.NoError(t, err)
+	retryId, err := accountant.WriteTransactionOnce(txn, dt)
+	assert.NoError(t, err)
+	assert.Equal(t, jrnId, retryId)
+
+	changed := sa.NewSimpleTransactionBuilder(0, "1210", "4100", 200).WithSource("INV").WithReference(42).Build()
+	_, err = accountant.WriteTransactionOnce(changed, dt)
+	assert.ErrorIs(t, err, sa.ErrJournalConflict)
+
+	teardownAccountantTest(t)
+}
+
+func TestAccountant_NoteWithWildcards(t *testing.T) {
+	setupAccountantTest(t)
+	def, _ := sa.NewChartDefinition("../tests/_data/personal.xml")
+	_, _ = accountant.CreateChart("Test", "GBP", def)
+	assertNoteWithWildcards(t, accountant, "mysql")
+	teardownAccountantTest(t)
+}
+
+func TestAccountant_WriteTransactionOnceInTx(t *testing.T) {
+	setupAccountantTest(t)
+	assertKeyedJournalInTx(t, sa.NewMysqlStore(db), "mysql")
+	teardownAccountantTest(t)
+}
+
+func TestAccountant_WriteCurrencyTransaction(t *testing.T) {
+	setupAccountantTest(t)
+	def, _ := sa.NewChartDefinition("../tests/_data/personal.xml")
+	_, _ = accountant.CreateChart("Test", "GBP", def)
+
+	txn := sa.NewSplitTransactionBuilder(0).
+		WithEntry(*sa.NewEntry("1210", 8500, *sa.NewAcType().Dr())).
+		WithEntry(*sa.NewCurrencyEntry("4100", 8500, *sa.NewAcType().Cr(), "EUR", 10000, 0.85)).
+		Build()
+	jrnId, err := accountant.WriteTransaction(txn)
+	assert.NoError(t, err)
+	jrn, err := accountant.FetchTransaction(jrnId)
+	assert.NoError(t, err)
+	bank, _ := jrn.GetEntry("1210")
+	assert.Equal(t, "", bank.Crcy())
+	income, _ := jrn.GetEntry("4100")
+	assert.Equal(t, int64(8500), income.Amount())
+	assert.Equal(t, "EUR", income.Crcy())
+	assert.Equal(t, int64(10000), income.CrcyAmount())
+	assert.Equal(t, 0.85, income.Rate())
 
 	teardownAccountantTest(t)
 }
@@ -187,10 +348,12 @@ func setupAccountantTest(t *testing.T) {
 		DBName:               os.Getenv("DBNAME"),
 		AllowNativePasswords: true,
 		ParseTime:            true,
+		MultiStatements:      true,
 	}
 	dba, err := sql.Open("mysql", config.FormatDSN())
 	assert.NoError(t, err)
-	accountant = sa.NewAccountant(dba, 0, "GBP")
+	assert.NoError(t, sa.Migrate(dba))
+	accountant = sa.NewAccountant(sa.NewMysqlStore(dba), 0, "GBP")
 	db = dba
 }
 
@@ -0,0 +1,58 @@
+package sa
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import "context"
+
+//AccountantTx is an Accountant whose operations are carried out within a single storage transaction.
+//It is only valid inside the function given to Accountant.InTx
+type AccountantTx struct {
+	*Accountant
+}
+
+//InTx runs f within a storage transaction.
+//If f returns nil the transaction is committed, else it is rolled back and the error from f is returned
+func (a *Accountant) InTx(f func(tx *AccountantTx) error) error {
+	return a.InTxContext(context.Background(), f)
+}
+
+//InTxContext runs f within a storage transaction.
+//If f returns nil the transaction is committed, else it is rolled back and the error from f is returned
+func (a *Accountant) InTxContext(ctx context.Context, f func(tx *AccountantTx) error) error {
+	if err := a.checkSchema(ctx); err != nil {
+		return err
+	}
+	txStore, err := a.store.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	tx := &AccountantTx{
+		Accountant: NewAccountant(txStore, a.chartId, a.crcy),
+	}
+	tx.schemaOk = true
+	tx.rates = a.rates
+
+	committed := false
+	defer func() {
+		if !committed {
+			_ = txStore.Rollback()
+		}
+	}()
+	if err = f(tx); err != nil {
+		return err
+	}
+	if err = txStore.Commit(); err != nil {
+		return err
+	}
+	committed = true
+
+	//pick up a chart created within the transaction
+	a.chartId = tx.chartId
+	return nil
+}
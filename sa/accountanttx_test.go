@@ -0,0 +1,112 @@
+//go:build unit
+// +build unit
+
+package sa_test
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"errors"
+	"github.com/chippyash/go-simple-accounts/sa"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var errTxTest = errors.New("payment failed")
+
+func TestAccountant_InTxCommitsAllWrites(t *testing.T) {
+	for name, accountant := range storeTestAccountants(t) {
+		var jrnIds []uint64
+		err := accountant.InTx(func(tx *sa.AccountantTx) error {
+			for _, txn := range txTestTransactions() {
+				jrnId, err := tx.WriteTransaction(txn)
+				if err != nil {
+					return err
+				}
+				jrnIds = append(jrnIds, jrnId)
+			}
+			return nil
+		})
+		assert.NoError(t, err, name)
+		for _, jrnId := range jrnIds {
+			_, err = accountant.FetchTransaction(jrnId)
+			assert.NoError(t, err, name)
+		}
+		chart, _ := accountant.FetchChart()
+		assert.Equal(t, int64(120), chart.GetAccount("1210").Dr(), name)
+		assert.Equal(t, int64(20), chart.GetAccount("1210").Cr(), name)
+	}
+}
+
+func TestAccountant_InTxRollsBackAllWritesOnError(t *testing.T) {
+	for name, accountant := range storeTestAccountants(t) {
+		var jrnIds []uint64
+		err := accountant.InTx(func(tx *sa.AccountantTx) error {
+			for _, txn := range txTestTransactions() {
+				jrnId, err := tx.WriteTransaction(txn)
+				if err != nil {
+					return err
+				}
+				jrnIds = append(jrnIds, jrnId)
+			}
+			nom := sa.MustNewNominal("1230")
+			prnt := sa.MustNewNominal("1200")
+			if err := tx.AddAccount(nom, sa.NewAcType().Bank(), "Deposit Account", &prnt); err != nil {
+				return err
+			}
+			if err := tx.DelAccount(sa.MustNewNominal("1800")); err != nil {
+				return err
+			}
+			return errTxTest
+		})
+		assert.ErrorIs(t, err, errTxTest, name)
+		assert.Equal(t, 3, len(jrnIds), name)
+		for _, jrnId := range jrnIds {
+			_, err = accountant.FetchTransaction(jrnId)
+			assert.ErrorIs(t, err, sa.ErrJournalNotFound, name)
+		}
+		chart, _ := accountant.FetchChart()
+		assert.Equal(t, int64(0), chart.GetAccount("0000").Dr(), name)
+		assert.Equal(t, int64(0), chart.GetAccount("0000").Cr(), name)
+		assert.False(t, chart.HasAccount("1230"), name)
+		assert.True(t, chart.HasAccount("1800"), name)
+	}
+}
+
+func TestAccountant_InTxCannotBeNested(t *testing.T) {
+	for name, accountant := range storeTestAccountants(t) {
+		err := accountant.InTx(func(tx *sa.AccountantTx) error {
+			return tx.InTx(func(tx *sa.AccountantTx) error {
+				return nil
+			})
+		})
+		assert.ErrorIs(t, err, sa.ErrNestedTransaction, name)
+	}
+}
+
+//txTestTransactions returns an invoice, its VAT and a payment for the invoice
+func txTestTransactions() []*sa.SplitTransaction {
+	return []*sa.SplitTransaction{
+		sa.NewSimpleTransactionBuilder(0, "1210", "4100", 100).Build(),
+		sa.NewSimpleTransactionBuilder(0, "1210", "4200", 20).Build(),
+		sa.NewSimpleTransactionBuilder(0, "6120", "1210", 20).Build(),
+	}
+}
+
+//the stores that can be tested without a database server
+func init() {
+	testAccountants["memory"] = func(t *testing.T) *sa.Accountant {
+		setupMemoryStoreTest(t)
+		return memAccountant
+	}
+	testAccountants["sqlite"] = func(t *testing.T) *sa.Accountant {
+		setupSqliteStoreTest(t)
+		return sqliteAccountant
+	}
+}
@@ -0,0 +1,165 @@
+package sa
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+//AccountStatementLine is a journal entry in an AccountStatement
+type AccountStatementLine struct {
+	JrnId uint64
+	Date  time.Time
+	Src   string
+	Ref   uint64
+	Note  string
+	//Nominal is the account the entry was posted to. It is a child account of the
+	//statement account if the statement includes child accounts
+	Nominal Nominal
+	Dr      int64
+	Cr      int64
+	//Balance is the running balance of the statement account after this entry
+	Balance int64
+}
+
+//AccountStatement lists the journal entries for an account between two dates, in date order,
+//with a running balance. Balances follow the sign convention of the account type,
+//so a debit increases the balance of a BANK account and a credit increases the balance of an INCOME account
+type AccountStatement struct {
+	Nominal Nominal
+	Name    string
+	Type    *AccountType
+	From    time.Time
+	To      time.Time
+	//OpeningBalance is the balance from the journals dated before From
+	OpeningBalance int64
+	Lines          []AccountStatementLine
+	//ClosingBalance is the balance after the last line
+	ClosingBalance int64
+}
+
+//AccountStatement returns the statement for an account, for journals dated between from and to inclusive.
+//A zero from starts the statement at the first journal, and a zero to ends it at the last journal
+func (a *Accountant) AccountStatement(nominal Nominal, from, to time.Time) (*AccountStatement, error) {
+	return a.AccountStatementContext(context.Background(), nominal, from, to)
+}
+
+//AccountStatementContext returns the statement for an account, for journals dated between from and to inclusive
+func (a *Accountant) AccountStatementContext(ctx context.Context, nominal Nominal, from, to time.Time) (*AccountStatement, error) {
+	return a.accountStatement(ctx, nominal, from, to, false)
+}
+
+//AccountStatementWithChildren returns the statement for an account, including the entries posted to all
+//of its child accounts, so that a statement can be produced for a parent ledger such as At Bank
+func (a *Accountant) AccountStatementWithChildren(nominal Nominal, from, to time.Time) (*AccountStatement, error) {
+	return a.AccountStatementWithChildrenContext(context.Background(), nominal, from, to)
+}
+
+//AccountStatementWithChildrenContext returns the statement for an account, including the entries posted to all
+//of its child accounts
+func (a *Accountant) AccountStatementWithChildrenContext(ctx context.Context, nominal Nominal, from, to time.Time) (*AccountStatement, error) {
+	return a.accountStatement(ctx, nominal, from, to, true)
+}
+
+func (a *Accountant) accountStatement(ctx context.Context, nominal Nominal, from, to time.Time, withChildren bool) (*AccountStatement, error) {
+	if a.chartId == 0 {
+		return nil, ErrNoChartId
+	}
+	if err := a.checkSchema(ctx); err != nil {
+		return nil, err
+	}
+	ledgers, err := a.store.FetchLedgers(ctx, a.chartId)
+	if err != nil {
+		return nil, err
+	}
+	var ledger *Ledger
+	for i := range ledgers {
+		if ledgers[i].Nominal == nominal {
+			ledger = &ledgers[i]
+			break
+		}
+	}
+	if ledger == nil {
+		return nil, ErrBadNominal
+	}
+	acType, ok := GetNamedAccountTypes()[ledger.Tpe]
+	if !ok {
+		return nil, ErrBadAccountType
+	}
+
+	nominals := []Nominal{nominal}
+	if withChildren {
+		nominals = ledgers.descendants(ledger.Id, nominals)
+	}
+	lines := make([]AccountStatementLine, 0)
+	for _, nom := range nominals {
+		journals, err := a.store.FetchAccountJournals(ctx, a.chartId, nom)
+		if err != nil {
+			return nil, err
+		}
+		for _, jrn := range journals {
+			line := AccountStatementLine{
+				JrnId:   jrn.Id(),
+				Date:    jrn.Date(),
+				Src:     jrn.Src(),
+				Ref:     jrn.Ref(),
+				Note:    jrn.Note(),
+				Nominal: nom,
+			}
+			for _, entry := range jrn.Entries() {
+				if *entry.Type() == *NewAcType().Dr() {
+					line.Dr += entry.Amount()
+				} else {
+					line.Cr += entry.Amount()
+				}
+			}
+			lines = append(lines, line)
+		}
+	}
+	sort.SliceStable(lines, func(i, j int) bool {
+		if lines[i].Date.Equal(lines[j].Date) {
+			return lines[i].JrnId < lines[j].JrnId
+		}
+		return lines[i].Date.Before(lines[j].Date)
+	})
+
+	statement := &AccountStatement{
+		Nominal: nominal,
+		Name:    ledger.Name,
+		Type:    acType,
+		From:    from,
+		To:      to,
+		Lines:   make([]AccountStatementLine, 0),
+	}
+	var acDr, acCr int64
+	for _, line := range lines {
+		if !to.IsZero() && line.Date.After(to) {
+			break
+		}
+		acDr += line.Dr
+		acCr += line.Cr
+		balance, err := acType.Balance(acDr, acCr)
+		if err != nil {
+			return nil, err
+		}
+		if !from.IsZero() && line.Date.Before(from) {
+			statement.OpeningBalance = balance
+			continue
+		}
+		line.Balance = balance
+		statement.Lines = append(statement.Lines, line)
+	}
+	statement.ClosingBalance = statement.OpeningBalance
+	if len(statement.Lines) > 0 {
+		statement.ClosingBalance = statement.Lines[len(statement.Lines)-1].Balance
+	}
+	return statement, nil
+}
@@ -8,14 +8,20 @@ package sa
  * @license BSD-3-Clause See LICENSE.md
  */
 
-import "github.com/chippyash/go-hierarchy-tree/tree"
+import (
+	"github.com/chippyash/go-hierarchy-tree/tree"
+	"time"
+)
 
 //Chart is a COA
 type Chart struct {
-	id   uint64
-	tree tree.NodeIFace
-	name string
-	crcy string
+	id              uint64
+	tree            tree.NodeIFace
+	name            string
+	crcy            string
+	description     string
+	created         time.Time
+	fiscalYearStart time.Time
 }
 
 //NewChart Constructor. Returns a new COA
@@ -108,6 +114,29 @@ func (c *Chart) Crcy() string {
 	return c.crcy
 }
 
+//Description returns the COA description
+func (c *Chart) Description() string {
+	return c.description
+}
+
+//Created returns the time the COA was created. Zero if it is not known
+func (c *Chart) Created() time.Time {
+	return c.created
+}
+
+//FiscalYearStart returns the start of the first fiscal year of the COA. Zero if it is not set
+func (c *Chart) FiscalYearStart() time.Time {
+	return c.fiscalYearStart
+}
+
+//withInfo sets the metadata of the COA from its stored info
+func (c *Chart) withInfo(info *ChartInfo) *Chart {
+	c.description = info.Description
+	c.created = info.Created
+	c.fiscalYearStart = info.FiscalYearStart
+	return c
+}
+
 //SetRootNode directly sets the COA root node
 func (c *Chart) SetRootNode(root tree.NodeIFace) *Chart {
 	c.tree = root
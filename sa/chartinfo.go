@@ -0,0 +1,130 @@
+package sa
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+//ChartInfo is the name and metadata of a chart of accounts
+type ChartInfo struct {
+	Id   uint64
+	Name string
+	//Crcy is the base currency of the chart (3 char crcy code). Empty if not set
+	Crcy        string
+	Description string
+	//Created is set by the store when the chart is created
+	Created time.Time
+	//FiscalYearStart is the start of the first fiscal year. Its month and day start every fiscal year
+	FiscalYearStart time.Time
+}
+
+//CreateChartWithInfo creates a new chart of accounts from a COA definition file, storing its metadata
+func (a *Accountant) CreateChartWithInfo(info ChartInfo, def *ChartDefinition) (uint64, error) {
+	return a.CreateChartWithInfoContext(context.Background(), info, def)
+}
+
+//CreateChartWithInfoContext creates a new chart of accounts from a COA definition file, storing its metadata,
+//in one storage transaction. An empty currency is the Accountant's currency. ErrCurrencyMismatch is returned if the
+//currencies differ
+func (a *Accountant) CreateChartWithInfoContext(ctx context.Context, info ChartInfo, def *ChartDefinition) (uint64, error) {
+	if info.Crcy == "" {
+		info.Crcy = a.crcy
+	}
+	if info.Crcy != "" && !validCrcy(info.Crcy) {
+		return 0, ErrBadChartCurrency
+	}
+	if a.crcy != "" && info.Crcy != a.crcy {
+		return 0, fmt.Errorf("%w: chart is %s, accountant is %s", ErrCurrencyMismatch, info.Crcy, a.crcy)
+	}
+	if _, ok := a.store.(TxStore); ok {
+		return a.createChartWithInfo(ctx, info, def)
+	}
+	var chartId uint64
+	err := a.InTxContext(ctx, func(tx *AccountantTx) error {
+		var err error
+		chartId, err = tx.createChartWithInfo(ctx, info, def)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return chartId, nil
+}
+
+func (a *Accountant) createChartWithInfo(ctx context.Context, info ChartInfo, def *ChartDefinition) (uint64, error) {
+	chartId, err := a.createChart(ctx, info.Name, def)
+	if err != nil {
+		return chartId, err
+	}
+	return chartId, a.store.SetChartInfo(ctx, chartId, info)
+}
+
+//FetchChartInfo returns the name and metadata of the chart
+func (a *Accountant) FetchChartInfo() (*ChartInfo, error) {
+	return a.FetchChartInfoContext(context.Background())
+}
+
+//FetchChartInfoContext returns the name and metadata of the chart
+func (a *Accountant) FetchChartInfoContext(ctx context.Context) (*ChartInfo, error) {
+	if a.chartId == 0 {
+		return nil, ErrNoChartId
+	}
+	if err := a.checkSchema(ctx); err != nil {
+		return nil, err
+	}
+	return a.store.FetchChartInfo(ctx, a.chartId)
+}
+
+//SetChartInfo sets the description and fiscal year start of the chart. The currency of a chart cannot be changed
+func (a *Accountant) SetChartInfo(description string, fiscalYearStart time.Time) error {
+	return a.SetChartInfoContext(context.Background(), description, fiscalYearStart)
+}
+
+//SetChartInfoContext sets the description and fiscal year start of the chart
+func (a *Accountant) SetChartInfoContext(ctx context.Context, description string, fiscalYearStart time.Time) error {
+	info, err := a.FetchChartInfoContext(ctx)
+	if err != nil {
+		return err
+	}
+	info.Description = description
+	info.FiscalYearStart = fiscalYearStart
+	return a.store.SetChartInfo(ctx, a.chartId, *info)
+}
+
+//chartCrcy returns the currency of a chart's info, or the Accountant's currency if the chart has none.
+//ErrCurrencyMismatch is returned if both are set and differ
+func (a *Accountant) chartCrcy(info *ChartInfo) (string, error) {
+	if info.Crcy == "" {
+		return a.crcy, nil
+	}
+	if a.crcy != "" && info.Crcy != a.crcy {
+		return "", fmt.Errorf("%w: chart is %s, accountant is %s", ErrCurrencyMismatch, info.Crcy, a.crcy)
+	}
+	return info.Crcy, nil
+}
+
+//baseCrcy returns the currency of the chart, from its stored info, or the Accountant's currency if the chart has none.
+//ErrBadCurrency is returned if neither is set
+func (a *Accountant) baseCrcy(ctx context.Context) (string, error) {
+	info, err := a.store.FetchChartInfo(ctx, a.chartId)
+	if err != nil {
+		return "", err
+	}
+	crcy, err := a.chartCrcy(info)
+	if err != nil {
+		return "", err
+	}
+	if crcy == "" {
+		return "", ErrBadCurrency
+	}
+	return crcy, nil
+}
@@ -0,0 +1,110 @@
+//go:build unit
+// +build unit
+
+package sa_test
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/chippyash/go-simple-accounts/sa"
+	"github.com/chippyash/go-simple-accounts/sa/sqlite"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAccountant_ChartInfo(t *testing.T) {
+	fyStart, _ := time.Parse(time.RFC3339, "2021-04-06T00:00:00Z")
+	for name, accountant := range storeTestAccountants(t) {
+		info, err := accountant.FetchChartInfo()
+		assert.NoError(t, err, name)
+		assert.Equal(t, "Test", info.Name, name)
+		assert.Equal(t, "GBP", info.Crcy, name)
+		assert.False(t, info.Created.IsZero(), name)
+		assert.True(t, info.FiscalYearStart.IsZero(), name)
+
+		assert.NoError(t, accountant.SetChartInfo("Personal accounts", fyStart), name)
+		chart, err := accountant.FetchChart()
+		assert.NoError(t, err, name)
+		assert.Equal(t, "GBP", chart.Crcy(), name)
+		assert.Equal(t, "Personal accounts", chart.Description(), name)
+		assert.True(t, fyStart.Equal(chart.FiscalYearStart()), name)
+		assert.True(t, info.Created.Equal(chart.Created()), name)
+	}
+}
+
+func TestAccountant_ChartCurrency(t *testing.T) {
+	dba, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "sa.db")+"?_foreign_keys=1")
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = dba.Close() })
+	assert.NoError(t, sa.Migrate(dba))
+	def, err := sa.NewChartDefinition("../tests/_data/personal.xml")
+	assert.NoError(t, err)
+
+	for name, store := range map[string]sa.Store{"memory": sa.NewMemoryStore(), "sqlite": sqlite.NewStore(dba)} {
+		_, err = sa.NewAccountant(store, 0, "GBP").CreateChart("Test", "EUR", def)
+		assert.ErrorIs(t, err, sa.ErrCurrencyMismatch, name)
+		_, err = sa.NewAccountant(store, 0, "").CreateChart("Test", "euro", def)
+		assert.ErrorIs(t, err, sa.ErrBadChartCurrency, name)
+
+		chartId, err := sa.NewAccountant(store, 0, "").CreateChartWithInfo(sa.ChartInfo{Name: "Euro", Crcy: "EUR", Description: "Euro accounts"}, def)
+		assert.NoError(t, err, name)
+
+		//an accountant without a currency uses the chart currency
+		chart, err := sa.NewAccountant(store, chartId, "").FetchChart()
+		assert.NoError(t, err, name)
+		assert.Equal(t, "EUR", chart.Crcy(), name)
+		assert.Equal(t, "Euro accounts", chart.Description(), name)
+
+		_, err = sa.NewAccountant(store, chartId, "USD").FetchChart()
+		assert.ErrorIs(t, err, sa.ErrCurrencyMismatch, name)
+	}
+}
+
+func TestAccountant_CreateChartWithInfoIsAtomic(t *testing.T) {
+	def, err := sa.NewChartDefinition("../tests/_data/personal.xml")
+	assert.NoError(t, err)
+	store := sa.NewMemoryStore()
+
+	accountant := sa.NewAccountant(failingInfoStore{store}, 0, "GBP")
+	_, err = accountant.CreateChartWithInfo(sa.ChartInfo{Name: "Test"}, def)
+	assert.ErrorIs(t, err, errSetChartInfo)
+	charts, _ := sa.ListCharts(store)
+	assert.Equal(t, 0, len(charts))
+
+	_, err = sa.NewAccountant(store, 0, "GBP").CreateChartWithInfo(sa.ChartInfo{Name: "Test"}, def)
+	assert.NoError(t, err)
+}
+
+var errSetChartInfo = errors.New("set chart info failed")
+
+//failingInfoStore is a Store whose transactions fail to set chart info
+type failingInfoStore struct {
+	sa.Store
+}
+
+func (s failingInfoStore) Begin(ctx context.Context) (sa.TxStore, error) {
+	tx, err := s.Store.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return failingInfoTx{tx}, nil
+}
+
+type failingInfoTx struct {
+	sa.TxStore
+}
+
+func (failingInfoTx) SetChartInfo(context.Context, uint64, sa.ChartInfo) error {
+	return errSetChartInfo
+}
@@ -0,0 +1,151 @@
+package sa
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"context"
+	"database/sql"
+)
+
+//ListCharts returns the name and metadata of every chart held in a Store, ordered by id
+func ListCharts(store Store) ([]ChartInfo, error) {
+	return ListChartsContext(context.Background(), store)
+}
+
+//ListChartsContext returns the name and metadata of every chart held in a Store, ordered by id
+func ListChartsContext(ctx context.Context, store Store) ([]ChartInfo, error) {
+	return store.ListCharts(ctx)
+}
+
+//ListDbCharts returns the name and metadata of every chart in a database, ordered by id.
+//The Store is chosen by the driver that db was opened with, as for NewDbStore
+func ListDbCharts(db *sql.DB) ([]ChartInfo, error) {
+	return ListDbChartsContext(context.Background(), db)
+}
+
+//ListDbChartsContext returns the name and metadata of every chart in a database, ordered by id.
+//The Store is chosen by the driver that db was opened with, as for NewDbStore
+func ListDbChartsContext(ctx context.Context, db *sql.DB) ([]ChartInfo, error) {
+	store, err := NewDbStore(db)
+	if err != nil {
+		return nil, err
+	}
+	return store.ListCharts(ctx)
+}
+
+//RenameChart renames the chart. ErrChartExists is returned if another chart has the name
+func (a *Accountant) RenameChart(name string) error {
+	return a.RenameChartContext(context.Background(), name)
+}
+
+//RenameChartContext renames the chart. ErrChartExists is returned if another chart has the name
+func (a *Accountant) RenameChartContext(ctx context.Context, name string) error {
+	if a.chartId == 0 {
+		return ErrNoChartId
+	}
+	if err := a.checkSchema(ctx); err != nil {
+		return err
+	}
+	return a.store.RenameChart(ctx, a.chartId, name)
+}
+
+//DeleteChart deletes the chart with its ledgers, journals and periods.
+//ErrChartHasBalances is returned if any ledger has a non-zero balance, unless force is true
+func (a *Accountant) DeleteChart(force bool) error {
+	return a.DeleteChartContext(context.Background(), force)
+}
+
+//DeleteChartContext deletes the chart with its ledgers, journals and periods.
+//ErrChartHasBalances is returned if any ledger has a non-zero balance, unless force is true
+func (a *Accountant) DeleteChartContext(ctx context.Context, force bool) error {
+	if a.chartId == 0 {
+		return ErrNoChartId
+	}
+	if err := a.checkSchema(ctx); err != nil {
+		return err
+	}
+	if !force {
+		ledgers, err := a.store.FetchLedgers(ctx, a.chartId)
+		if err != nil {
+			return err
+		}
+		for _, ledger := range ledgers {
+			if ledger.AcDr != ledger.AcCr {
+				return ErrChartHasBalances
+			}
+		}
+	}
+	if err := a.store.DeleteChart(ctx, a.chartId); err != nil {
+		return err
+	}
+	a.chartId = 0
+	return nil
+}
+
+//CloneChart creates a new chart with the ledgers, currency, description and fiscal year start of the chart.
+//The ledgers of the new chart have zero balances. Returns the id of the new chart
+func (a *Accountant) CloneChart(newName string) (uint64, error) {
+	return a.CloneChartContext(context.Background(), newName)
+}
+
+//CloneChartContext creates a new chart with the ledgers, currency, description and fiscal year start of the chart
+func (a *Accountant) CloneChartContext(ctx context.Context, newName string) (uint64, error) {
+	if a.chartId == 0 {
+		return 0, ErrNoChartId
+	}
+	if err := a.checkSchema(ctx); err != nil {
+		return 0, err
+	}
+	if _, ok := a.store.(TxStore); ok {
+		return a.cloneChart(ctx, newName)
+	}
+	var chartId uint64
+	err := a.InTxContext(ctx, func(tx *AccountantTx) error {
+		var err error
+		chartId, err = tx.cloneChart(ctx, newName)
+		return err
+	})
+	return chartId, err
+}
+
+func (a *Accountant) cloneChart(ctx context.Context, newName string) (uint64, error) {
+	info, err := a.store.FetchChartInfo(ctx, a.chartId)
+	if err != nil {
+		return 0, err
+	}
+	ledgers, err := a.store.FetchLedgers(ctx, a.chartId)
+	if err != nil {
+		return 0, err
+	}
+	if len(ledgers) == 0 {
+		return 0, ErrNoChartLedgers
+	}
+
+	chartId, err := a.store.CreateChart(ctx, newName)
+	if err != nil {
+		return 0, err
+	}
+	if err = a.store.SetChartInfo(ctx, chartId, *info); err != nil {
+		return 0, err
+	}
+	//a parent ledger is always fetched before its children
+	nominals := make(map[uint64]Nominal, len(ledgers))
+	for _, ledger := range ledgers {
+		acType, ok := GetNamedAccountTypes()[ledger.Tpe]
+		if !ok {
+			return 0, ErrBadAccountType
+		}
+		err = a.store.AddLedger(ctx, chartId, ledger.Nominal, acType, ledger.Name, nominals[ledger.PrntId])
+		if err != nil {
+			return 0, err
+		}
+		nominals[ledger.Id] = ledger.Nominal
+	}
+	return chartId, nil
+}
@@ -0,0 +1,101 @@
+//go:build unit
+// +build unit
+
+package sa_test
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"database/sql"
+	"github.com/chippyash/go-simple-accounts/sa"
+	"github.com/chippyash/go-simple-accounts/sa/sqlite"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+)
+
+func TestAccountant_ManageCharts(t *testing.T) {
+	dba, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "sa.db")+"?_foreign_keys=1")
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = dba.Close() })
+	assert.NoError(t, sa.Migrate(dba))
+	def, err := sa.NewChartDefinition("../tests/_data/personal.xml")
+	assert.NoError(t, err)
+
+	for name, store := range map[string]sa.Store{"memory": sa.NewMemoryStore(), "sqlite": sqlite.NewStore(dba)} {
+		accountant := sa.NewAccountant(store, 0, "GBP")
+		chartId, err := accountant.CreateChartWithInfo(sa.ChartInfo{Name: "Client A", Description: "Client A accounts"}, def)
+		assert.NoError(t, err, name)
+		_, err = sa.NewAccountant(store, 0, "GBP").CreateChart("Client B", "GBP", def)
+		assert.NoError(t, err, name)
+
+		charts, err := sa.ListCharts(store)
+		assert.NoError(t, err, name)
+		assert.Equal(t, 2, len(charts), name)
+		assert.Equal(t, chartId, charts[0].Id, name)
+		assert.Equal(t, "Client A", charts[0].Name, name)
+		assert.Equal(t, "GBP", charts[0].Crcy, name)
+		assert.Equal(t, "Client B", charts[1].Name, name)
+
+		assert.ErrorIs(t, accountant.RenameChart("Client B"), sa.ErrChartExists, name)
+		assert.NoError(t, accountant.RenameChart("Client C"), name)
+		chart, _ := accountant.FetchChart()
+		assert.Equal(t, "Client C", chart.Name(), name)
+
+		txn := sa.NewSplitTransactionBuilder(0).
+			WithEntry(*sa.NewEntry("1210", 1500, *sa.NewAcType().Dr())).
+			WithEntry(*sa.NewEntry("4100", 1500, *sa.NewAcType().Cr())).
+			Build()
+		_, err = accountant.WriteTransaction(txn)
+		assert.NoError(t, err, name)
+
+		//the clone has the same ledgers with zero balances
+		cloneId, err := accountant.CloneChart("Client D")
+		assert.NoError(t, err, name)
+		clone, err := sa.NewAccountant(store, cloneId, "GBP").FetchChart()
+		assert.NoError(t, err, name)
+		assert.Equal(t, "Client D", clone.Name(), name)
+		assert.Equal(t, "Client A accounts", clone.Description(), name)
+		assert.Equal(t, chart.Tree().GetHeight(), clone.Tree().GetHeight(), name)
+		assert.Equal(t, sa.Nominal("0001"), clone.GetParentId(sa.MustNewNominal("1000")), name)
+		bank := clone.GetAccount("1210")
+		assert.NotNil(t, bank, name)
+		assert.Equal(t, int64(0), bank.Dr(), name)
+		_, err = accountant.CloneChart("Client B")
+		assert.ErrorIs(t, err, sa.ErrChartExists, name)
+
+		assert.ErrorIs(t, accountant.DeleteChart(false), sa.ErrChartHasBalances, name)
+		assert.NoError(t, accountant.DeleteChart(true), name)
+		assert.ErrorIs(t, accountant.DeleteChart(true), sa.ErrNoChartId, name)
+		assert.ErrorIs(t, sa.NewAccountant(store, chartId, "GBP").DeleteChart(true), sa.ErrChartNotFound, name)
+		assert.NoError(t, sa.NewAccountant(store, cloneId, "GBP").DeleteChart(false), name)
+
+		charts, err = sa.ListCharts(store)
+		assert.NoError(t, err, name)
+		assert.Equal(t, 1, len(charts), name)
+		assert.Equal(t, "Client B", charts[0].Name, name)
+	}
+
+	//the journals of the deleted charts have gone with them
+	var cnt int
+	assert.NoError(t, dba.QueryRow("select count(id) from sa_journal").Scan(&cnt))
+	assert.Equal(t, 0, cnt)
+}
+
+func TestListDbCharts(t *testing.T) {
+	setupSqliteStoreTest(t)
+	charts, err := sa.ListDbCharts(sqliteDb)
+	assert.NoError(t, err)
+	if assert.Equal(t, 1, len(charts)) {
+		assert.Equal(t, "Test", charts[0].Name)
+	}
+	store, err := sa.NewDbStore(sqliteDb)
+	assert.NoError(t, err)
+	assert.IsType(t, &sa.SqlStore{}, store)
+}
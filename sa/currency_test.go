@@ -0,0 +1,114 @@
+//go:build unit || integration
+// +build unit integration
+
+package sa_test
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"github.com/chippyash/go-simple-accounts/sa"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestNewCurrencyEntry(t *testing.T) {
+	sut := sa.NewCurrencyEntry("4100", 8500, *sa.NewAcType().Cr(), "EUR", 10000, 0.85)
+	assert.Equal(t, sa.Nominal("4100"), *sut.Id())
+	assert.Equal(t, int64(8500), sut.Amount())
+	assert.Equal(t, "EUR", sut.Crcy())
+	assert.Equal(t, int64(10000), sut.CrcyAmount())
+	assert.Equal(t, 0.85, sut.Rate())
+
+	sut = sa.NewEntry("4100", 8500, *sa.NewAcType().Cr())
+	assert.Equal(t, "", sut.Crcy())
+	assert.Equal(t, int64(0), sut.CrcyAmount())
+	assert.Equal(t, 0.0, sut.Rate())
+}
+
+func TestStores_WriteCurrencyTransaction(t *testing.T) {
+	dt, _ := time.Parse(time.RFC3339, "2020-07-01T12:00:00Z")
+	for name, accountant := range storeTestAccountants(t) {
+		txn := sa.NewSplitTransactionBuilder(0).
+			WithSource("INV").
+			WithReference(1).
+			WithEntry(*sa.NewEntry("1210", 8500, *sa.NewAcType().Dr())).
+			WithEntry(*sa.NewCurrencyEntry("4100", 8500, *sa.NewAcType().Cr(), "EUR", 10000, 0.85)).
+			Build()
+		jrnId, err := accountant.WriteTransactionWithDate(txn, dt)
+		assert.NoError(t, err, name)
+
+		jrn, err := accountant.FetchTransaction(jrnId)
+		assert.NoError(t, err, name)
+		assertCurrencyEntries(t, jrn, name)
+
+		page, err := accountant.QueryJournals(sa.NewJournalQuery().WithSource("INV"))
+		assert.NoError(t, err, name)
+		assertCurrencyEntries(t, page.Journals[0], name)
+
+		it, err := accountant.Journals(sa.NewJournalQuery().WithSource("INV"))
+		assert.NoError(t, err, name)
+		assert.True(t, it.Next(), name)
+		assertCurrencyEntries(t, it.Journal(), name)
+		assert.NoError(t, it.Close(), name)
+
+		balance, _ := accountant.BalanceAsAt("4100", dt)
+		assert.Equal(t, int64(8500), balance, name)
+
+		//reversals are in the same currency
+		revId, err := accountant.ReverseTransaction(jrnId, dt, "reverse")
+		assert.NoError(t, err, name)
+		rev, _ := accountant.FetchTransaction(revId)
+		entry, err := rev.GetEntry("4100")
+		assert.NoError(t, err, name)
+		assert.Equal(t, *sa.NewAcType().Dr(), *entry.Type(), name)
+		assert.Equal(t, "EUR", entry.Crcy(), name)
+		assert.Equal(t, int64(10000), entry.CrcyAmount(), name)
+	}
+}
+
+func TestAccountant_WriteCurrencyTransactionIsCheckedInChartCurrency(t *testing.T) {
+	for name, accountant := range storeTestAccountants(t) {
+		//the transaction currency amounts balance, but the chart currency amounts do not
+		txn := sa.NewSplitTransactionBuilder(0).
+			WithEntry(*sa.NewCurrencyEntry("1210", 8500, *sa.NewAcType().Dr(), "EUR", 10000, 0.85)).
+			WithEntry(*sa.NewCurrencyEntry("4100", 8600, *sa.NewAcType().Cr(), "EUR", 10000, 0.86)).
+			Build()
+		_, err := accountant.WriteTransaction(txn)
+		assert.ErrorIs(t, err, sa.ErrUnbalancedTransaction, name)
+
+		for _, entry := range []*sa.Entry{
+			sa.NewCurrencyEntry("4100", 8500, *sa.NewAcType().Cr(), "euro", 10000, 0.85),
+			sa.NewCurrencyEntry("4100", 8500, *sa.NewAcType().Cr(), "EUR", 0, 0.85),
+			sa.NewCurrencyEntry("4100", 8500, *sa.NewAcType().Cr(), "EUR", 10000, 0),
+			sa.NewCurrencyEntry("4100", 8500, *sa.NewAcType().Cr(), "", 10000, 0.85),
+		} {
+			txn = sa.NewSplitTransactionBuilder(0).
+				WithEntry(*sa.NewEntry("1210", 8500, *sa.NewAcType().Dr())).
+				WithEntry(*entry).
+				Build()
+			_, err = accountant.WriteTransaction(txn)
+			assert.ErrorIs(t, err, sa.ErrBadEntryCurrency, name)
+		}
+	}
+}
+
+func assertCurrencyEntries(t *testing.T, jrn *sa.SplitTransaction, name string) {
+	assert.True(t, jrn.CheckBalance(), name)
+	bank, err := jrn.GetEntry("1210")
+	assert.NoError(t, err, name)
+	assert.Equal(t, int64(8500), bank.Amount(), name)
+	assert.Equal(t, "", bank.Crcy(), name)
+	income, err := jrn.GetEntry("4100")
+	assert.NoError(t, err, name)
+	assert.Equal(t, int64(8500), income.Amount(), name)
+	assert.Equal(t, "EUR", income.Crcy(), name)
+	assert.Equal(t, int64(10000), income.CrcyAmount(), name)
+	assert.Equal(t, 0.85, income.Rate(), name)
+}
@@ -11,7 +11,8 @@ package sa
 //Entries is a set of transaction entries
 type Entries []*Entry
 
-//CheckBalance returns true if the set of entries balance else false
+//CheckBalance returns true if the set of entries balance else false.
+//The chart currency amounts are used, so entries in another currency balance at their converted amounts
 func (e *Entries) CheckBalance() bool {
 	var balance int64 = 0
 	drAc := *NewAcType().Dr()
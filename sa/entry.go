@@ -12,6 +12,12 @@ type Entry struct {
 	entryId *Nominal
 	amount  int64
 	tpe     *AccountType
+	//crcy is the transaction currency, empty if the entry is in the chart currency
+	crcy       string
+	crcyAmount int64
+	rate       float64
+	//moneyCrcy is the currency of the Money that the amount was given as, empty if it was not given as Money
+	moneyCrcy string
 }
 
 //NewEntry constructor for Entry
@@ -23,6 +29,26 @@ func NewEntry(entryId Nominal, amount int64, tpe AccountType) *Entry {
 	}
 }
 
+//NewCurrencyEntry constructor for an Entry in a transaction currency that is not the chart currency.
+//amount is in the chart currency, and is the amount used to balance the transaction.
+//crcyAmount is the amount in the transaction currency crcy, and rate is the rate for whole units used to convert it
+func NewCurrencyEntry(entryId Nominal, amount int64, tpe AccountType, crcy string, crcyAmount int64, rate float64) *Entry {
+	entry := NewEntry(entryId, amount, tpe)
+	entry.crcy = crcy
+	entry.crcyAmount = crcyAmount
+	entry.rate = rate
+	return entry
+}
+
+//NewMoneyEntry constructor for an Entry of an amount of Money.
+//Money in the chart currency is the Entry Amount. Money in another currency is the Entry CrcyAmount,
+//and is converted to the chart currency with the Accountant's exchange rate provider when it is written
+func NewMoneyEntry(entryId Nominal, amount Money, tpe AccountType) *Entry {
+	entry := NewEntry(entryId, amount.Amount(), tpe)
+	entry.moneyCrcy = amount.Crcy()
+	return entry
+}
+
 //Id returns the Entry Id
 func (e *Entry) Id() *Nominal {
 	return e.entryId
@@ -37,3 +63,65 @@ func (e *Entry) Type() *AccountType {
 func (e *Entry) Amount() int64 {
 	return e.amount
 }
+
+//Crcy returns the transaction currency of the Entry, or an empty string if it is in the chart currency
+func (e *Entry) Crcy() string {
+	return e.crcy
+}
+
+//CrcyAmount returns the Entry Amount in its transaction currency
+func (e *Entry) CrcyAmount() int64 {
+	return e.crcyAmount
+}
+
+//Rate returns the rate used to convert the transaction currency amount to the chart currency Amount
+func (e *Entry) Rate() float64 {
+	return e.rate
+}
+
+//withType returns a copy of the Entry with a different type
+func (e *Entry) withType(tpe AccountType) *Entry {
+	entry := *e
+	entry.tpe = &tpe
+	return &entry
+}
+
+//validCurrency returns false if the Entry has a transaction currency that is not a three letter code,
+//or a transaction currency amount or rate that is not positive
+func (e *Entry) validCurrency() bool {
+	if e.crcy == "" {
+		return e.crcyAmount == 0 && e.rate == 0
+	}
+	return validCrcy(e.crcy) && e.crcyAmount > 0 && e.rate > 0
+}
+
+//inCrcy makes an Entry given as Money in a currency other than the chart currency crcy an Entry in that
+//transaction currency, to be converted to the chart currency
+func (e *Entry) inCrcy(crcy string) {
+	if e.moneyCrcy == "" || crcy == "" || e.moneyCrcy == crcy || e.crcy != "" {
+		return
+	}
+	e.crcy = e.moneyCrcy
+	e.crcyAmount = e.amount
+	e.amount = 0
+}
+
+//convert sets the rate of an Entry in a transaction currency, and its Amount in the chart currency crcy.
+//The rate is for whole units, and is scaled by the minor units of the currencies
+func (e *Entry) convert(rate float64, crcy string) {
+	e.rate = rate
+	e.amount = convertAmount(e.crcyAmount, rate, e.crcy, crcy)
+}
+
+//validCrcy returns true if crcy is a three letter upper case currency code
+func validCrcy(crcy string) bool {
+	if len(crcy) != 3 {
+		return false
+	}
+	for _, c := range crcy {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
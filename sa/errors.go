@@ -8,7 +8,11 @@ package sa
  * @license BSD-3-Clause See LICENSE.md
  */
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 var (
 	ErrNoChartId             = errors.New("chart id not set")
@@ -21,4 +25,79 @@ var (
 	ErrBadNominal            = errors.New("provided value for Nominal does not match pattern: " + NOMINAL_REGEX)
 	ErrEntryNotFound         = errors.New("entry not found")
 	ErrUnbalancedTransaction = errors.New("transaction is not balanced")
+	ErrJournalNotFound       = errors.New("cannot retrieve journal")
+	ErrNoChartLedgers        = errors.New("chart has no ledgers")
+	ErrChartNotFound         = errors.New("chart not found")
+	ErrChartExists           = errors.New("chart name already exists")
+	ErrRootExists            = errors.New("chart already has root account")
+	ErrBadParent             = errors.New("invalid parent account nominal")
+	ErrNominalExists         = errors.New("account nominal already exists in chart")
+	ErrNonZeroBalance        = errors.New("account balance is non zero")
+	ErrNestedTransaction     = errors.New("store is already in a transaction")
+	ErrTxConflict            = errors.New("store was written to by another writer during the transaction")
+	ErrSchemaVersion         = errors.New("unsupported database schema version")
+	ErrDirtySchema           = errors.New("database schema is dirty, a migration failed")
+	ErrJournalVoid           = errors.New("journal is void")
+	ErrReversalJournal       = errors.New("cannot reverse a reversing journal")
+	ErrUnknownDriver         = errors.New("database driver is not registered")
+	ErrBadCursor             = errors.New("invalid journal query cursor")
+	ErrNoJournalKey          = errors.New("journal src and ref are required for an idempotent write")
+	ErrDuplicateJournal      = errors.New("journal src and ref already exist in chart")
+	ErrJournalConflict       = errors.New("journal src and ref already exist with different entries")
+	ErrPeriodClosed          = errors.New("date is in a closed period")
+	ErrPeriodLocked          = errors.New("period is locked")
+	ErrPeriodNotFound        = errors.New("period not found")
+	ErrPeriodOverlap         = errors.New("period overlaps another period")
+	ErrBadPeriod             = errors.New("period must end after it starts")
+	ErrBadPeriodStatus       = errors.New("unknown period status")
+	ErrBadPeriodPattern      = errors.New("unknown fiscal year period pattern")
+	ErrYearClosed            = errors.New("year has already been closed")
+	ErrNotEquityAccount      = errors.New("retained earnings account must be an equity account")
+	ErrBadOpeningBalance     = errors.New("opening balance must have a nominal and amounts that are not negative")
+	ErrNoOpeningBalances     = errors.New("no opening balances to post")
+	ErrUnbalancedOpening     = errors.New("opening balances do not net to zero")
+	ErrOpeningBalancesPosted = errors.New("opening balances have already been posted")
+	ErrBadEntryCurrency      = errors.New("entry currency must be a three letter code with a positive amount and rate")
+	ErrNoExchangeRate        = errors.New("no exchange rate for currency")
+	ErrRevaluationOrder      = errors.New("revaluation date is before the previous revaluation")
+	ErrBadExchangeRate       = errors.New("exchange rate must have a date, three letter currency codes and a positive rate")
+	ErrBadChartCurrency      = errors.New("chart currency must be a three letter code")
+	ErrCurrencyMismatch      = errors.New("chart currency does not match the accountant currency")
+	ErrChartHasBalances      = errors.New("chart has ledgers with non-zero balances")
+	ErrBadCurrency           = errors.New("currency must be a three letter code")
+	ErrBadMoney              = errors.New("money must be a decimal with no more decimal places than its currency")
+	ErrMoneyOverflow         = errors.New("money amount overflows")
+	ErrMoneyCurrency         = errors.New("money currencies differ")
 )
+
+//JournalConflictError is returned by an idempotent write when a journal with the same src and ref
+//already exists in the chart, but with different entries. It wraps ErrJournalConflict
+type JournalConflictError struct {
+	Src string
+	Ref uint64
+	//JrnId is the id of the existing journal
+	JrnId uint64
+}
+
+func (e *JournalConflictError) Error() string {
+	return fmt.Sprintf("%s: src %s ref %d is journal %d", ErrJournalConflict, e.Src, e.Ref, e.JrnId)
+}
+
+func (e *JournalConflictError) Unwrap() error {
+	return ErrJournalConflict
+}
+
+//PeriodClosedError is returned when a journal is written with a date in a closed or locked period.
+//It wraps ErrPeriodClosed
+type PeriodClosedError struct {
+	Date   time.Time
+	Period Period
+}
+
+func (e *PeriodClosedError) Error() string {
+	return fmt.Sprintf("%s: %s is in %s period %s", ErrPeriodClosed, e.Date.Format(time.RFC3339), e.Period.Status, e.Period.Name)
+}
+
+func (e *PeriodClosedError) Unwrap() error {
+	return ErrPeriodClosed
+}
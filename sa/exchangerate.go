@@ -0,0 +1,287 @@
+package sa
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//ExchangeRate is the rate that converts an amount in From to an amount in To, from Date until the next rate for the pair
+type ExchangeRate struct {
+	From string
+	To   string
+	Date time.Time
+	//Rate is the amount of To for one unit of From
+	Rate float64
+}
+
+//ExchangeRateProvider provides exchange rates
+type ExchangeRateProvider interface {
+	//Rate returns the rate that converts an amount in from to an amount in to, at dt.
+	//ErrNoExchangeRate is returned if there is no rate
+	Rate(ctx context.Context, from, to string, dt time.Time) (float64, error)
+}
+
+//StoreRateProvider is an ExchangeRateProvider that uses the latest rate, on or before the date, held in a Store
+type StoreRateProvider struct {
+	store Store
+	pivot string
+}
+
+//NewStoreRateProvider returns an ExchangeRateProvider for the exchange rates held in a Store
+func NewStoreRateProvider(store Store) *StoreRateProvider {
+	return &StoreRateProvider{store: store}
+}
+
+//WithPivot sets a currency through which a cross rate is calculated when there is no rate between two currencies,
+//e.g. EUR for rates loaded from the ECB
+func (p *StoreRateProvider) WithPivot(crcy string) *StoreRateProvider {
+	p.pivot = crcy
+	return p
+}
+
+//Rate returns the rate that converts an amount in from to an amount in to, at dt.
+//The inverse of the rate for to and from is used if there is no rate for from and to
+func (p *StoreRateProvider) Rate(ctx context.Context, from, to string, dt time.Time) (float64, error) {
+	rate, err := p.pairRate(ctx, from, to, dt)
+	if !errors.Is(err, ErrNoExchangeRate) || p.pivot == "" || from == p.pivot || to == p.pivot {
+		return rate, err
+	}
+	toPivot, err := p.pairRate(ctx, from, p.pivot, dt)
+	if err != nil {
+		return 0, err
+	}
+	fromPivot, err := p.pairRate(ctx, p.pivot, to, dt)
+	if err != nil {
+		return 0, err
+	}
+	return toPivot * fromPivot, nil
+}
+
+//pairRate returns the stored rate for from and to, or the inverse of the stored rate for to and from
+func (p *StoreRateProvider) pairRate(ctx context.Context, from, to string, dt time.Time) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+	rate, err := p.store.FetchExchangeRate(ctx, from, to, dt)
+	if err == nil {
+		return rate.Rate, nil
+	}
+	if !errors.Is(err, ErrNoExchangeRate) {
+		return 0, err
+	}
+	rate, err = p.store.FetchExchangeRate(ctx, to, from, dt)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s to %s at %s", ErrNoExchangeRate, from, to, dt.Format("2006-01-02"))
+	}
+	return 1 / rate.Rate, nil
+}
+
+//ReadExchangeRatesCSV reads exchange rates from CSV records of date,from,to,rate, with dates as yyyy-mm-dd.
+//The first record may be a header of column names
+func ReadExchangeRatesCSV(r io.Reader) ([]ExchangeRate, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) > 0 && strings.EqualFold(strings.TrimSpace(records[0][0]), "date") {
+		records = records[1:]
+	}
+	rates := make([]ExchangeRate, len(records))
+	for i, record := range records {
+		rate, err := newExchangeRate(record[0], record[1], record[2], record[3])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		rates[i] = rate
+	}
+	return rates, nil
+}
+
+//ecbEnvelope is the European Central Bank euro foreign exchange reference rates XML,
+//e.g. https://www.ecb.europa.eu/stats/eurofxref/eurofxref-hist.xml
+type ecbEnvelope struct {
+	Days []struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string `xml:"currency,attr"`
+			Rate     string `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+//ReadExchangeRatesECB reads the European Central Bank euro foreign exchange reference rates XML.
+//The rates are from EUR
+func ReadExchangeRatesECB(r io.Reader) ([]ExchangeRate, error) {
+	envelope := ecbEnvelope{}
+	if err := xml.NewDecoder(r).Decode(&envelope); err != nil {
+		return nil, err
+	}
+	rates := make([]ExchangeRate, 0)
+	for _, day := range envelope.Days {
+		for _, r := range day.Rates {
+			rate, err := newExchangeRate(day.Time, "EUR", r.Currency, r.Rate)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", day.Time, r.Currency, err)
+			}
+			rates = append(rates, rate)
+		}
+	}
+	return rates, nil
+}
+
+//newExchangeRate returns an ExchangeRate for text values
+func newExchangeRate(date, from, to, rate string) (ExchangeRate, error) {
+	dt, err := time.Parse("2006-01-02", strings.TrimSpace(date))
+	if err != nil {
+		return ExchangeRate{}, ErrBadExchangeRate
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(rate), 64)
+	if err != nil || value <= 0 {
+		return ExchangeRate{}, ErrBadExchangeRate
+	}
+	ex := ExchangeRate{From: strings.TrimSpace(from), To: strings.TrimSpace(to), Date: dt, Rate: value}
+	if !validCrcy(ex.From) || !validCrcy(ex.To) {
+		return ExchangeRate{}, ErrBadExchangeRate
+	}
+	return ex, nil
+}
+
+//AddExchangeRates stores exchange rates, replacing any rate for the same currency pair and date
+func (a *Accountant) AddExchangeRates(rates []ExchangeRate) error {
+	return a.AddExchangeRatesContext(context.Background(), rates)
+}
+
+//AddExchangeRatesContext stores exchange rates, replacing any rate for the same currency pair and date
+func (a *Accountant) AddExchangeRatesContext(ctx context.Context, rates []ExchangeRate) error {
+	if err := a.checkSchema(ctx); err != nil {
+		return err
+	}
+	for _, rate := range rates {
+		if rate.Date.IsZero() || !validCrcy(rate.From) || !validCrcy(rate.To) || rate.Rate <= 0 {
+			return ErrBadExchangeRate
+		}
+	}
+	return a.store.AddExchangeRates(ctx, rates)
+}
+
+//WithExchangeRateProvider sets the provider of the rates used to convert entries in another currency that
+//do not have a rate. By default the rates held in the Accountant's store are used
+func (a *Accountant) WithExchangeRateProvider(provider ExchangeRateProvider) *Accountant {
+	a.rates = provider
+	return a
+}
+
+//rateProvider returns the exchange rate provider, or a provider for the rates in the store
+func (a *Accountant) rateProvider() ExchangeRateProvider {
+	if a.rates != nil {
+		return a.rates
+	}
+	return NewStoreRateProvider(a.store)
+}
+
+//convertEntries returns a copy of the transaction in which the entries in another currency that do not have a rate
+//have their rate and chart currency amount set, using the exchange rate provider. The transaction itself is not changed.
+//The entries converted from each currency are rounded by side, so that a transaction that balances in that currency
+//still balances in the chart currency.
+//ErrBadCurrency is returned if an entry has a currency and the chart currency is not known
+func (a *Accountant) convertEntries(ctx context.Context, txn *SplitTransaction, dt time.Time) (*SplitTransaction, error) {
+	converted := *txn
+	converted.entries = make(Entries, len(txn.entries))
+	crcy := ""
+	rates := make(map[string]float64)
+	byCrcy := make(map[string][]*Entry)
+	for i, e := range txn.entries {
+		entry := *e
+		converted.entries[i] = &entry
+		if entry.moneyCrcy == "" && entry.crcy == "" {
+			continue
+		}
+		if crcy == "" {
+			var err error
+			if crcy, err = a.baseCrcy(ctx); err != nil {
+				return nil, err
+			}
+		}
+		entry.inCrcy(crcy)
+		if entry.Crcy() == "" || entry.Rate() != 0 || entry.Amount() != 0 {
+			continue
+		}
+		rate, ok := rates[entry.Crcy()]
+		if !ok {
+			var err error
+			if rate, err = a.rateProvider().Rate(ctx, entry.Crcy(), crcy, dt); err != nil {
+				return nil, err
+			}
+			rates[entry.Crcy()] = rate
+		}
+		entry.convert(rate, crcy)
+		byCrcy[entry.Crcy()] = append(byCrcy[entry.Crcy()], &entry)
+	}
+	for from, entries := range byCrcy {
+		roundBySide(entries, rates[from], crcy)
+	}
+	return &converted, nil
+}
+
+//roundBySide adjusts the chart currency amounts of entries converted from one currency at the same rate, so that
+//the amounts on each side add up to the converted total of that side. The difference is put on the largest entry
+func roundBySide(entries []*Entry, rate float64, crcy string) {
+	drAc := *NewAcType().Dr()
+	for _, dr := range []bool{true, false} {
+		var crcyTotal, total int64
+		var largest *Entry
+		for _, entry := range entries {
+			if (*entry.Type()&drAc == drAc) != dr {
+				continue
+			}
+			crcyTotal += entry.crcyAmount
+			total += entry.amount
+			if largest == nil || entry.crcyAmount > largest.crcyAmount {
+				largest = entry
+			}
+		}
+		if largest == nil {
+			continue
+		}
+		largest.amount += convertAmount(crcyTotal, rate, largest.crcy, crcy) - total
+	}
+}
+
+//convertAmount converts an amount in minor units of from to minor units of to, rounded to the nearest minor unit.
+//The rate is for whole units, as rates are quoted, e.g. 0.0055 JPY to GBP converts 1000 yen to 550 pence
+func convertAmount(amount int64, rate float64, from, to string) int64 {
+	return int64(math.Round(float64(amount) * minorUnitRate(rate, from, to)))
+}
+
+//minorUnitRate returns the rate that converts minor units of from to minor units of to,
+//for a rate that converts whole units, e.g. 0.0055 JPY to GBP is 0.55 pence for a yen
+func minorUnitRate(rate float64, from, to string) float64 {
+	fromExp, err := CurrencyExponent(from)
+	if err != nil {
+		return rate
+	}
+	toExp, err := CurrencyExponent(to)
+	if err != nil {
+		return rate
+	}
+	return rate * math.Pow10(toExp-fromExp)
+}
@@ -0,0 +1,241 @@
+//go:build unit
+// +build unit
+
+package sa_test
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"context"
+	"database/sql"
+	"github.com/chippyash/go-simple-accounts/sa"
+	"github.com/chippyash/go-simple-accounts/sa/sqlite"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const ecbRates = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<gesmes:Sender>
+		<gesmes:name>European Central Bank</gesmes:name>
+	</gesmes:Sender>
+	<Cube>
+		<Cube time="2021-01-29">
+			<Cube currency="USD" rate="1.2136"/>
+			<Cube currency="GBP" rate="0.8848"/>
+		</Cube>
+		<Cube time="2021-01-28">
+			<Cube currency="USD" rate="1.2108"/>
+			<Cube currency="GBP" rate="0.8835"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+func TestReadExchangeRatesCSV(t *testing.T) {
+	rates, err := sa.ReadExchangeRatesCSV(strings.NewReader("date,from,to,rate\n2021-01-29,EUR,GBP,0.8848\n2021-01-29, USD, GBP, 0.7291\n"))
+	assert.NoError(t, err)
+	dt, _ := time.Parse(time.RFC3339, "2021-01-29T00:00:00Z")
+	assert.Equal(t, []sa.ExchangeRate{
+		{From: "EUR", To: "GBP", Date: dt, Rate: 0.8848},
+		{From: "USD", To: "GBP", Date: dt, Rate: 0.7291},
+	}, rates)
+
+	for _, line := range []string{
+		"29/01/2021,EUR,GBP,0.8848",
+		"2021-01-29,euro,GBP,0.8848",
+		"2021-01-29,EUR,GBP,-1",
+		"2021-01-29,EUR,GBP,rate",
+	} {
+		_, err = sa.ReadExchangeRatesCSV(strings.NewReader(line))
+		assert.ErrorIs(t, err, sa.ErrBadExchangeRate, line)
+	}
+}
+
+func TestReadExchangeRatesECB(t *testing.T) {
+	rates, err := sa.ReadExchangeRatesECB(strings.NewReader(ecbRates))
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(rates))
+	dt, _ := time.Parse(time.RFC3339, "2021-01-29T00:00:00Z")
+	assert.Equal(t, sa.ExchangeRate{From: "EUR", To: "GBP", Date: dt, Rate: 0.8848}, rates[1])
+
+	_, err = sa.ReadExchangeRatesECB(strings.NewReader("not xml"))
+	assert.Error(t, err)
+}
+
+func TestStoreRateProvider(t *testing.T) {
+	dba, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "sa.db")+"?_foreign_keys=1")
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = dba.Close() })
+	assert.NoError(t, sa.Migrate(dba))
+	ecb, _ := sa.ReadExchangeRatesECB(strings.NewReader(ecbRates))
+	ctx := context.Background()
+	thursday, _ := time.Parse(time.RFC3339, "2021-01-28T12:00:00Z")
+	weekend, _ := time.Parse(time.RFC3339, "2021-01-31T12:00:00Z")
+
+	for name, store := range map[string]sa.Store{"memory": sa.NewMemoryStore(), "sqlite": sqlite.NewStore(dba)} {
+		assert.NoError(t, store.AddExchangeRates(ctx, ecb), name)
+		provider := sa.NewStoreRateProvider(store)
+
+		//the nearest earlier rate is used
+		rate, err := provider.Rate(ctx, "EUR", "GBP", weekend)
+		assert.NoError(t, err, name)
+		assert.Equal(t, 0.8848, rate, name)
+		rate, err = provider.Rate(ctx, "EUR", "GBP", thursday)
+		assert.NoError(t, err, name)
+		assert.Equal(t, 0.8835, rate, name)
+		_, err = provider.Rate(ctx, "EUR", "GBP", thursday.AddDate(0, 0, -1))
+		assert.ErrorIs(t, err, sa.ErrNoExchangeRate, name)
+
+		//inverse and cross rates
+		rate, err = provider.Rate(ctx, "GBP", "EUR", weekend)
+		assert.NoError(t, err, name)
+		assert.InDelta(t, 1/0.8848, rate, 1e-9, name)
+		_, err = provider.Rate(ctx, "USD", "GBP", weekend)
+		assert.ErrorIs(t, err, sa.ErrNoExchangeRate, name)
+		rate, err = provider.WithPivot("EUR").Rate(ctx, "USD", "GBP", weekend)
+		assert.NoError(t, err, name)
+		assert.InDelta(t, 0.8848/1.2136, rate, 1e-9, name)
+
+		//a rate for the same pair and date is replaced
+		dt, _ := time.Parse(time.RFC3339, "2021-01-29T00:00:00Z")
+		assert.NoError(t, store.AddExchangeRates(ctx, []sa.ExchangeRate{{From: "EUR", To: "GBP", Date: dt, Rate: 0.89}}), name)
+		fetched, err := store.FetchExchangeRate(ctx, "EUR", "GBP", weekend)
+		assert.NoError(t, err, name)
+		assert.Equal(t, 0.89, fetched.Rate, name)
+		assert.True(t, dt.Equal(fetched.Date), name)
+	}
+}
+
+func TestAccountant_ConvertsEntriesWithoutRate(t *testing.T) {
+	dt, _ := time.Parse(time.RFC3339, "2021-01-31T12:00:00Z")
+	for name, accountant := range storeTestAccountants(t) {
+		txn := func() *sa.SplitTransaction {
+			return sa.NewSplitTransactionBuilder(0).
+				WithEntry(*sa.NewCurrencyEntry("1220", 0, *sa.NewAcType().Dr(), "EUR", 10000, 0)).
+				WithEntry(*sa.NewCurrencyEntry("4100", 0, *sa.NewAcType().Cr(), "EUR", 10000, 0)).
+				Build()
+		}
+		_, err := accountant.WriteTransactionWithDate(txn(), dt)
+		assert.ErrorIs(t, err, sa.ErrNoExchangeRate, name)
+
+		assert.NoError(t, accountant.AddExchangeRates([]sa.ExchangeRate{{From: "EUR", To: "GBP", Date: dt.AddDate(0, 0, -2), Rate: 0.8848}}), name)
+		assert.ErrorIs(t, accountant.AddExchangeRates([]sa.ExchangeRate{{From: "EUR", To: "GBP", Date: dt}}), sa.ErrBadExchangeRate, name)
+		assert.ErrorIs(t, accountant.AddExchangeRates([]sa.ExchangeRate{{From: "EUR", To: "GBP", Rate: 0.8848}}), sa.ErrBadExchangeRate, name)
+		written := txn()
+		jrnId, err := accountant.WriteTransactionWithDate(written, dt)
+		assert.NoError(t, err, name)
+		jrn, _ := accountant.FetchTransaction(jrnId)
+		entry, _ := jrn.GetEntry("1220")
+		assert.Equal(t, int64(8848), entry.Amount(), name)
+		assert.Equal(t, 0.8848, entry.Rate(), name)
+		assert.Equal(t, int64(10000), entry.CrcyAmount(), name)
+
+		//the converted entries are a copy
+		entry, _ = written.GetEntry("1220")
+		assert.Equal(t, int64(0), entry.Amount(), name)
+		assert.Equal(t, 0.0, entry.Rate(), name)
+
+		//the rates are used within a transaction
+		err = accountant.InTx(func(tx *sa.AccountantTx) error {
+			_, err := tx.WriteTransactionWithDate(txn(), dt)
+			return err
+		})
+		assert.NoError(t, err, name)
+
+		//and by a revaluation
+		_, err = accountant.Revalue(dt, nil, "4200")
+		assert.NoError(t, err, name)
+	}
+}
+
+func TestAccountant_ConvertsEntriesByMinorUnits(t *testing.T) {
+	dt, _ := time.Parse(time.RFC3339, "2021-01-31T12:00:00Z")
+	for name, accountant := range storeTestAccountants(t) {
+		//JPY has no minor unit, so 10000 is ¥10,000, which is £55.00 at 0.0055
+		assert.NoError(t, accountant.AddExchangeRates([]sa.ExchangeRate{{From: "JPY", To: "GBP", Date: dt, Rate: 0.0055}}), name)
+		txn := sa.NewSplitTransactionBuilder(0).
+			WithEntry(*sa.NewCurrencyEntry("1220", 0, *sa.NewAcType().Dr(), "JPY", 10000, 0)).
+			WithEntry(*sa.NewCurrencyEntry("4100", 0, *sa.NewAcType().Cr(), "JPY", 10000, 0)).
+			Build()
+		jrnId, err := accountant.WriteTransactionWithDate(txn, dt)
+		assert.NoError(t, err, name)
+		jrn, _ := accountant.FetchTransaction(jrnId)
+		entry, _ := jrn.GetEntry("1220")
+		assert.Equal(t, int64(5500), entry.Amount(), name)
+		assert.Equal(t, 0.0055, entry.Rate(), name)
+		assert.Equal(t, int64(10000), entry.CrcyAmount(), name)
+	}
+}
+
+func TestAccountant_ConvertedEntriesBalance(t *testing.T) {
+	dt, _ := time.Parse(time.RFC3339, "2021-01-31T12:00:00Z")
+	for name, accountant := range storeTestAccountants(t) {
+		//EUR 3.00 is £2.36 at 0.785, but each EUR 1.00 is £0.79
+		assert.NoError(t, accountant.AddExchangeRates([]sa.ExchangeRate{{From: "EUR", To: "GBP", Date: dt, Rate: 0.785}}), name)
+		txn := sa.NewSplitTransactionBuilder(0).
+			WithEntry(*sa.NewCurrencyEntry("1210", 0, *sa.NewAcType().Dr(), "EUR", 300, 0)).
+			WithEntry(*sa.NewCurrencyEntry("4100", 0, *sa.NewAcType().Cr(), "EUR", 100, 0)).
+			WithEntry(*sa.NewCurrencyEntry("4200", 0, *sa.NewAcType().Cr(), "EUR", 100, 0)).
+			WithEntry(*sa.NewCurrencyEntry("1220", 0, *sa.NewAcType().Cr(), "EUR", 100, 0)).
+			Build()
+		jrnId, err := accountant.WriteTransactionWithDate(txn, dt)
+		assert.NoError(t, err, name)
+		jrn, _ := accountant.FetchTransaction(jrnId)
+		assert.True(t, jrn.CheckBalance(), name)
+		var credits int64
+		for _, entry := range jrn.Entries() {
+			if entry.Id().String() == "1210" {
+				assert.Equal(t, int64(236), entry.Amount(), name)
+			} else {
+				credits += entry.Amount()
+			}
+		}
+		assert.Equal(t, int64(236), credits, name)
+	}
+}
+
+func TestAccountant_ConvertsEntriesToStoredChartCurrency(t *testing.T) {
+	dt, _ := time.Parse(time.RFC3339, "2021-01-31T12:00:00Z")
+	setupSqliteStoreTest(t)
+	def, _ := sa.NewChartDefinition("../tests/_data/personal.xml")
+	for name, store := range map[string]sa.Store{
+		"memory": sa.NewMemoryStore(),
+		"sqlite": sqlite.NewStore(sqliteDb),
+	} {
+		//the chart currency is only held in the chart info
+		chartId, err := sa.NewAccountant(store, 0, "").CreateChartWithInfo(sa.ChartInfo{Name: "Base", Crcy: "GBP"}, def)
+		assert.NoError(t, err, name)
+		accountant := sa.NewAccountant(store, chartId, "")
+		assert.NoError(t, accountant.AddExchangeRates([]sa.ExchangeRate{{From: "USD", To: "GBP", Date: dt, Rate: 0.8}}), name)
+		txn := sa.NewSplitTransactionBuilder(0).
+			WithEntry(*sa.NewMoneyEntry("1220", sa.MustNewMoney(10000, "USD"), *sa.NewAcType().Dr())).
+			WithEntry(*sa.NewMoneyEntry("4100", sa.MustNewMoney(10000, "USD"), *sa.NewAcType().Cr())).
+			Build()
+		jrnId, err := accountant.WriteTransactionWithDate(txn, dt)
+		assert.NoError(t, err, name)
+		jrn, _ := accountant.FetchTransaction(jrnId)
+		entry, _ := jrn.GetEntry("1220")
+		assert.Equal(t, "USD", entry.Crcy(), name)
+		assert.Equal(t, int64(8000), entry.Amount(), name)
+
+		_, err = accountant.Revalue(dt, map[string]float64{"USD": 0.9}, "4200")
+		assert.NoError(t, err, name)
+		assertBalances(t, accountant, dt, map[sa.Nominal]int64{"1220": 9000}, name)
+
+		//a chart without a currency cannot convert entries
+		chartId, err = sa.NewAccountant(store, 0, "").CreateChart("No currency", "", def)
+		assert.NoError(t, err, name)
+		_, err = sa.NewAccountant(store, chartId, "").WriteTransactionWithDate(txn, dt)
+		assert.ErrorIs(t, err, sa.ErrBadCurrency, name)
+	}
+}
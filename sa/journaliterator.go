@@ -0,0 +1,181 @@
+package sa
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+//JournalIterator returns journals one at a time, in the same way as sql.Rows.
+//Call Next before each call to Journal, and check Err when Next returns false.
+//Close must be called if the iterator is not read to the end
+type JournalIterator interface {
+	//Next moves to the next journal, returning false when there are no more journals or an error occurred
+	Next() bool
+	//Journal returns the current journal, with all its entries
+	Journal() *SplitTransaction
+	//Err returns the error, if any, that stopped the iteration
+	Err() error
+	//Close stops the iteration and releases its resources
+	Close() error
+}
+
+//Journals returns an iterator over the journals selected by the query, for reading a large number of
+//journals without holding them all in memory. The query limit is the total number of journals returned, and
+//a zero limit returns all the selected journals
+func (a *Accountant) Journals(q *JournalQuery) (JournalIterator, error) {
+	return a.JournalsContext(context.Background(), q)
+}
+
+//JournalsContext returns an iterator over the journals selected by the query
+func (a *Accountant) JournalsContext(ctx context.Context, q *JournalQuery) (JournalIterator, error) {
+	if a.chartId == 0 {
+		return nil, ErrNoChartId
+	}
+	if err := a.checkSchema(ctx); err != nil {
+		return nil, err
+	}
+	filter, err := a.journalFilter(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	return a.store.IterateJournals(ctx, a.chartId, filter)
+}
+
+//sliceJournalIterator is a JournalIterator over journals that are already in memory
+type sliceJournalIterator struct {
+	journals []*SplitTransaction
+	journal  *SplitTransaction
+}
+
+func (it *sliceJournalIterator) Next() bool {
+	if len(it.journals) == 0 {
+		it.journal = nil
+		return false
+	}
+	it.journal, it.journals = it.journals[0], it.journals[1:]
+	return true
+}
+
+func (it *sliceJournalIterator) Journal() *SplitTransaction {
+	return it.journal
+}
+
+func (it *sliceJournalIterator) Err() error {
+	return nil
+}
+
+func (it *sliceJournalIterator) Close() error {
+	it.journals = nil
+	return nil
+}
+
+//journalRow is a row of the join of a journal and one of its entries
+type journalRow struct {
+	id, ref, reversalOf, reversedBy uint64
+	note, src                       string
+	date                            time.Time
+	entry                           storedEntry
+}
+
+//rowsJournalIterator is a JournalIterator over the rows of a query joining journals to their entries,
+//ordered so that the entries of each journal are together
+type rowsJournalIterator struct {
+	rows  *sql.Rows
+	limit int
+	count int
+	//next is the first row of the next journal, read while building the current journal
+	next    *journalRow
+	journal *SplitTransaction
+	err     error
+}
+
+//iterateJournals runs a query joining the journals selected by the filter to their entries, and returns
+//an iterator over the result. q rewrites the ? bind parameters for the database
+func iterateJournals(ctx context.Context, db dbtx, q func(string) string, chartId uint64, f JournalFilter) (JournalIterator, error) {
+	where, orderBy, args := journalFilterSql(chartId, f)
+	rows, err := db.QueryContext(ctx, q(`
+select j.id, j.note, j.date, j.src, j.ref, coalesce(j.reversalOf, 0), coalesce(j.reversedBy, 0), `+entryColumns("e")+`
+from sa_journal as j
+left join sa_journal_entry as e
+on j.id = e.jrnId
+`+where+"\norder by "+orderBy+", e.id"), args...)
+	if err != nil {
+		return nil, err
+	}
+	return &rowsJournalIterator{rows: rows, limit: f.Limit}, nil
+}
+
+func (it *rowsJournalIterator) Next() bool {
+	it.journal = nil
+	if it.limit > 0 && it.count == it.limit {
+		it.err = it.rows.Close()
+		return false
+	}
+	row := it.next
+	if row == nil {
+		if row = it.scan(); row == nil {
+			return false
+		}
+	}
+	journal := NewSplitTransactionBuilder(row.id).
+		WithNote(row.note).
+		WithDate(row.date).
+		WithSource(row.src).
+		WithReference(row.ref).
+		WithReversalOf(row.reversalOf).
+		WithReversedBy(row.reversedBy)
+	//a journal without entries has one row, with an empty nominal
+	if row.entry.nominal != "" {
+		journal = journal.WithEntry(*row.entry.entry())
+	}
+	for {
+		it.next = it.scan()
+		if it.next == nil || it.next.id != row.id {
+			break
+		}
+		journal = journal.WithEntry(*it.next.entry.entry())
+	}
+	if it.err != nil {
+		return false
+	}
+	it.journal = journal.Build()
+	it.count++
+	return true
+}
+
+//scan reads the next row, returning nil at the end of the rows or on error
+func (it *rowsJournalIterator) scan() *journalRow {
+	if !it.rows.Next() {
+		it.err = it.rows.Err()
+		return nil
+	}
+	row := &journalRow{}
+	dest := []interface{}{&row.id, &row.note, &row.date, &row.src, &row.ref, &row.reversalOf, &row.reversedBy}
+	if err := it.rows.Scan(append(dest, row.entry.dest()...)...); err != nil {
+		it.err = err
+		_ = it.rows.Close()
+		return nil
+	}
+	return row
+}
+
+func (it *rowsJournalIterator) Journal() *SplitTransaction {
+	return it.journal
+}
+
+func (it *rowsJournalIterator) Err() error {
+	return it.err
+}
+
+func (it *rowsJournalIterator) Close() error {
+	return it.rows.Close()
+}
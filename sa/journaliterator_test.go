@@ -0,0 +1,98 @@
+//go:build unit || integration
+// +build unit integration
+
+package sa_test
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"context"
+	"github.com/chippyash/go-simple-accounts/sa"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestAccountant_JournalsIteratesInQueryOrder(t *testing.T) {
+	for name, accountant := range storeTestAccountants(t) {
+		writeQueryTestJournals(t, accountant)
+		//a journal with more than two entries
+		dt, _ := time.Parse(time.RFC3339, "2020-09-01T12:00:00Z")
+		txn := sa.NewSplitTransactionBuilder(0).
+			WithNote("Split").
+			WithEntry(*sa.NewEntry("1210", 150, *sa.NewAcType().Dr())).
+			WithEntry(*sa.NewEntry("4100", 100, *sa.NewAcType().Cr())).
+			WithEntry(*sa.NewEntry("4200", 50, *sa.NewAcType().Cr())).
+			Build()
+		_, err := accountant.WriteTransactionWithDate(txn, dt)
+		assert.NoError(t, err, name)
+
+		for test, tc := range map[string]struct {
+			query    *sa.JournalQuery
+			expected []uint64
+		}{
+			"all":             {sa.NewJournalQuery(), []uint64{5, 1, 2, 3, 4, 6}},
+			"filtered":        {sa.NewJournalQuery().ForAccount("4100").Descending(), []uint64{6, 3, 1}},
+			"limited":         {sa.NewJournalQuery().Limit(2), []uint64{5, 1}},
+			"no journals":     {sa.NewJournalQuery().WithSource("NONE"), []uint64{}},
+			"single journal":  {sa.NewJournalQuery().WithReference(3), []uint64{3}},
+			"split last":      {sa.NewJournalQuery().OrderBy(sa.JournalOrderId).ForAccount("1210"), []uint64{1, 2, 3, 6}},
+			"split first":     {sa.NewJournalQuery().ForAccount("4200").Descending(), []uint64{6, 5}},
+			"ordered by id":   {sa.NewJournalQuery().OrderBy(sa.JournalOrderId), []uint64{1, 2, 3, 4, 5, 6}},
+			"limit above all": {sa.NewJournalQuery().Limit(10).WithSource("BANK"), []uint64{2, 4}},
+		} {
+			it, err := accountant.Journals(tc.query)
+			assert.NoError(t, err, "%s: %s", name, test)
+			ids := make([]uint64, 0)
+			for it.Next() {
+				jrn := it.Journal()
+				ids = append(ids, jrn.Id())
+				assert.True(t, jrn.CheckBalance(), "%s: %s journal %d", name, test, jrn.Id())
+				if jrn.Id() == 6 {
+					assert.Equal(t, 3, len(jrn.Entries()), "%s: %s", name, test)
+					assert.Equal(t, "Split", jrn.Note(), "%s: %s", name, test)
+				} else {
+					assert.Equal(t, 2, len(jrn.Entries()), "%s: %s journal %d", name, test, jrn.Id())
+				}
+			}
+			assert.NoError(t, it.Err(), "%s: %s", name, test)
+			assert.NoError(t, it.Close(), "%s: %s", name, test)
+			assert.Equal(t, tc.expected, ids, "%s: %s", name, test)
+			assert.Nil(t, it.Journal(), "%s: %s", name, test)
+		}
+	}
+}
+
+func TestAccountant_JournalsResumesFromCursor(t *testing.T) {
+	for name, accountant := range storeTestAccountants(t) {
+		writeQueryTestJournals(t, accountant)
+		page, err := accountant.QueryJournals(sa.NewJournalQuery().Limit(2))
+		assert.NoError(t, err, name)
+
+		it, err := accountant.Journals(sa.NewJournalQuery().After(page.Next))
+		assert.NoError(t, err, name)
+		assert.True(t, it.Next(), name)
+		assert.Equal(t, uint64(2), it.Journal().Id(), name)
+		//stop before the end
+		assert.NoError(t, it.Close(), name)
+
+		_, err = accountant.Journals(sa.NewJournalQuery().After("not a cursor"))
+		assert.ErrorIs(t, err, sa.ErrBadCursor, name)
+	}
+}
+
+func TestAccountant_JournalsCancelledContext(t *testing.T) {
+	for name, accountant := range storeTestAccountants(t) {
+		writeQueryTestJournals(t, accountant)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := accountant.JournalsContext(ctx, sa.NewJournalQuery())
+		assert.ErrorIs(t, err, context.Canceled, name)
+	}
+}
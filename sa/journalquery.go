@@ -0,0 +1,466 @@
+package sa
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+//DefaultJournalPageSize is the number of journals in a JournalPage if the query has no limit
+const DefaultJournalPageSize = 100
+
+//JournalOrder is the order of the journals returned by a JournalQuery
+type JournalOrder int
+
+const (
+	//JournalOrderDate orders journals by date, and then by id for journals with the same date
+	JournalOrderDate JournalOrder = iota
+	//JournalOrderId orders journals by id, which is the order that they were written in
+	JournalOrderId
+)
+
+//JournalCursor is the position of a journal in the order of a query.
+//Journals after the cursor are those that follow it in the query order
+type JournalCursor struct {
+	Date time.Time
+	Id   uint64
+}
+
+//JournalFilter is the resolved form of a JournalQuery, as given to a Store.
+//Zero values do not filter
+type JournalFilter struct {
+	//From and To are the inclusive date range of the journals
+	From time.Time
+	To   time.Time
+	//Nominals selects journals with an entry for any of the ledgers
+	Nominals []Nominal
+	Src      string
+	Ref      uint64
+	//MinAmount and MaxAmount are the inclusive range of the journal amount, which is the sum of its debit entries
+	MinAmount int64
+	MaxAmount int64
+	//Note selects journals whose note contains the text, ignoring case
+	Note       string
+	Order      JournalOrder
+	Descending bool
+	//After selects journals after the cursor in the query order
+	After *JournalCursor
+	//Limit is the maximum number of journals returned
+	Limit int
+}
+
+//JournalQuery builds a query for the journals of a chart
+type JournalQuery struct {
+	filter   JournalFilter
+	accounts []Nominal
+	subtrees []Nominal
+	cursor   string
+}
+
+//JournalPage is a page of journals returned by a JournalQuery
+type JournalPage struct {
+	Journals []*SplitTransaction
+	//Next is the cursor for the next page, or empty if this is the last page
+	Next string
+}
+
+//NewJournalQuery returns a query for all journals, in date order
+func NewJournalQuery() *JournalQuery {
+	return &JournalQuery{}
+}
+
+//Between selects journals dated between from and to inclusive. A zero from or to leaves that end of the range open
+func (q *JournalQuery) Between(from, to time.Time) *JournalQuery {
+	q.filter.From = from
+	q.filter.To = to
+	return q
+}
+
+//ForAccount selects journals with an entry for the account. It can be called more than once to select
+//journals for any of the accounts
+func (q *JournalQuery) ForAccount(nominal Nominal) *JournalQuery {
+	q.accounts = append(q.accounts, nominal)
+	return q
+}
+
+//ForAccountWithChildren selects journals with an entry for the account or any of its child accounts
+func (q *JournalQuery) ForAccountWithChildren(nominal Nominal) *JournalQuery {
+	q.subtrees = append(q.subtrees, nominal)
+	return q
+}
+
+//WithSource selects journals with the src
+func (q *JournalQuery) WithSource(src string) *JournalQuery {
+	q.filter.Src = src
+	return q
+}
+
+//WithReference selects journals with the ref
+func (q *JournalQuery) WithReference(ref uint64) *JournalQuery {
+	q.filter.Ref = ref
+	return q
+}
+
+//WithAmountBetween selects journals whose amount is between min and max inclusive. A zero min or max
+//leaves that end of the range open
+func (q *JournalQuery) WithAmountBetween(min, max int64) *JournalQuery {
+	q.filter.MinAmount = min
+	q.filter.MaxAmount = max
+	return q
+}
+
+//WithNoteContaining selects journals whose note contains the text, ignoring case
+func (q *JournalQuery) WithNoteContaining(text string) *JournalQuery {
+	q.filter.Note = text
+	return q
+}
+
+//OrderBy sets the order of the journals
+func (q *JournalQuery) OrderBy(order JournalOrder) *JournalQuery {
+	q.filter.Order = order
+	return q
+}
+
+//Descending reverses the order of the journals, latest first
+func (q *JournalQuery) Descending() *JournalQuery {
+	q.filter.Descending = true
+	return q
+}
+
+//Limit sets the number of journals in a page. The default is DefaultJournalPageSize,
+//or all the journals for Accountant.Journals
+func (q *JournalQuery) Limit(limit int) *JournalQuery {
+	q.filter.Limit = limit
+	return q
+}
+
+//After starts the page after the cursor, which is the Next value of the previous JournalPage
+func (q *JournalQuery) After(cursor string) *JournalQuery {
+	q.cursor = cursor
+	return q
+}
+
+//QueryJournals returns a page of the journals selected by the query, with all their entries
+func (a *Accountant) QueryJournals(q *JournalQuery) (*JournalPage, error) {
+	return a.QueryJournalsContext(context.Background(), q)
+}
+
+//QueryJournalsContext returns a page of the journals selected by the query, with all their entries
+func (a *Accountant) QueryJournalsContext(ctx context.Context, q *JournalQuery) (*JournalPage, error) {
+	if a.chartId == 0 {
+		return nil, ErrNoChartId
+	}
+	if err := a.checkSchema(ctx); err != nil {
+		return nil, err
+	}
+	filter, err := a.journalFilter(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultJournalPageSize
+	}
+	limit := filter.Limit
+	//fetch one more than the page, to know if there is a next page
+	filter.Limit++
+
+	journals, err := a.store.QueryJournals(ctx, a.chartId, filter)
+	if err != nil {
+		return nil, err
+	}
+	page := &JournalPage{Journals: journals}
+	if len(journals) > limit {
+		page.Journals = journals[:limit]
+		page.Next = encodeCursor(page.Journals[limit-1])
+	}
+	return page, nil
+}
+
+//journalFilter resolves the cursor and accounts of a query into a JournalFilter
+func (a *Accountant) journalFilter(ctx context.Context, q *JournalQuery) (JournalFilter, error) {
+	filter := q.filter
+	if q.cursor != "" {
+		cursor, err := decodeCursor(q.cursor)
+		if err != nil {
+			return filter, err
+		}
+		filter.After = cursor
+	}
+	if len(q.accounts) > 0 || len(q.subtrees) > 0 {
+		nominals, err := a.queryNominals(ctx, q)
+		if err != nil {
+			return filter, err
+		}
+		filter.Nominals = nominals
+	}
+	return filter, nil
+}
+
+//queryNominals returns the nominals of the query accounts, and of the children of its subtree accounts
+func (a *Accountant) queryNominals(ctx context.Context, q *JournalQuery) ([]Nominal, error) {
+	ledgers, err := a.store.FetchLedgers(ctx, a.chartId)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[Nominal]uint64, len(ledgers))
+	for _, ledger := range ledgers {
+		ids[ledger.Nominal] = ledger.Id
+	}
+	nominals := make([]Nominal, 0)
+	for _, nominal := range q.accounts {
+		if _, ok := ids[nominal]; !ok {
+			return nil, ErrBadNominal
+		}
+		nominals = append(nominals, nominal)
+	}
+	for _, nominal := range q.subtrees {
+		id, ok := ids[nominal]
+		if !ok {
+			return nil, ErrBadNominal
+		}
+		nominals = ledgers.descendants(id, append(nominals, nominal))
+	}
+	return nominals, nil
+}
+
+//encodeCursor returns the cursor for the position of a journal
+func encodeCursor(jrn *SplitTransaction) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%d", jrn.Date().UnixNano(), jrn.Id())))
+}
+
+//decodeCursor returns the position held by a cursor
+func decodeCursor(cursor string) (*JournalCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrBadCursor
+	}
+	var nano int64
+	var id uint64
+	if n, err := fmt.Sscanf(string(b), "%d:%d", &nano, &id); err != nil || n != 2 {
+		return nil, ErrBadCursor
+	}
+	return &JournalCursor{Date: time.Unix(0, nano).UTC(), Id: id}, nil
+}
+
+//matches returns true if the journal is selected by the filter, ignoring the cursor and limit
+func (f JournalFilter) matches(jrn *SplitTransaction) bool {
+	if (!f.From.IsZero() && jrn.Date().Before(f.From)) || (!f.To.IsZero() && jrn.Date().After(f.To)) {
+		return false
+	}
+	if (f.Src != "" && jrn.Src() != f.Src) || (f.Ref != 0 && jrn.Ref() != f.Ref) {
+		return false
+	}
+	if f.Note != "" && !strings.Contains(strings.ToLower(jrn.Note()), strings.ToLower(f.Note)) {
+		return false
+	}
+	var amount int64
+	for _, entry := range jrn.Entries() {
+		if *entry.Type() == *NewAcType().Dr() {
+			amount += entry.Amount()
+		}
+	}
+	if (f.MinAmount != 0 && amount < f.MinAmount) || (f.MaxAmount != 0 && amount > f.MaxAmount) {
+		return false
+	}
+	if f.Nominals == nil {
+		return true
+	}
+	for _, nominal := range f.Nominals {
+		if _, err := jrn.GetEntry(nominal); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+//before returns true if journal i comes before journal j in the filter order
+func (f JournalFilter) before(i, j JournalCursor) bool {
+	if f.Order == JournalOrderDate && !i.Date.Equal(j.Date) {
+		return i.Date.Before(j.Date) != f.Descending
+	}
+	if f.Descending {
+		return i.Id > j.Id
+	}
+	return i.Id < j.Id
+}
+
+//page sorts the journals selected by the filter into its order, and returns those after its cursor, up to its limit
+func (f JournalFilter) page(journals []*SplitTransaction) []*SplitTransaction {
+	position := func(jrn *SplitTransaction) JournalCursor {
+		return JournalCursor{Date: jrn.Date(), Id: jrn.Id()}
+	}
+	sort.Slice(journals, func(i, j int) bool {
+		return f.before(position(journals[i]), position(journals[j]))
+	})
+	response := make([]*SplitTransaction, 0)
+	for _, jrn := range journals {
+		if f.After != nil && !f.before(*f.After, position(jrn)) {
+			continue
+		}
+		if f.Limit > 0 && len(response) == f.Limit {
+			break
+		}
+		response = append(response, jrn)
+	}
+	return response
+}
+
+//likeEscaper escapes the like wildcards, and the '!' escape character, in a note filter
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+//journalFilterSql returns the where clause, and its ? bind parameters, and the order by columns for a journal query.
+//The limit is not included
+func journalFilterSql(chartId uint64, f JournalFilter) (string, string, []interface{}) {
+	where := []string{"j.chartId = ?"}
+	args := []interface{}{chartId}
+	if !f.From.IsZero() {
+		where = append(where, "j.date >= ?")
+		args = append(args, f.From.UTC())
+	}
+	if !f.To.IsZero() {
+		where = append(where, "j.date <= ?")
+		args = append(args, f.To.UTC())
+	}
+	if f.Nominals != nil {
+		in := make([]string, len(f.Nominals))
+		for i, nominal := range f.Nominals {
+			in[i] = "?"
+			args = append(args, nominal.String())
+		}
+		where = append(where, "j.id in (select je.jrnId from sa_journal_entry as je where je.nominal in ("+strings.Join(in, ", ")+"))")
+	}
+	if f.Src != "" {
+		where = append(where, "j.src = ?")
+		args = append(args, f.Src)
+	}
+	if f.Ref != 0 {
+		where = append(where, "j.ref = ?")
+		args = append(args, f.Ref)
+	}
+	const amount = "(select sum(je.acDr) from sa_journal_entry as je where je.jrnId = j.id)"
+	if f.MinAmount != 0 {
+		where = append(where, amount+" >= ?")
+		args = append(args, f.MinAmount)
+	}
+	if f.MaxAmount != 0 {
+		where = append(where, amount+" <= ?")
+		args = append(args, f.MaxAmount)
+	}
+	if f.Note != "" {
+		//'!' escapes the wildcards in the note, as a backslash is not escaped in the same way by every database
+		where = append(where, "lower(j.note) like ? escape '!'")
+		args = append(args, "%"+likeEscaper.Replace(strings.ToLower(f.Note))+"%")
+	}
+
+	cmp, dir := ">", "asc"
+	if f.Descending {
+		cmp, dir = "<", "desc"
+	}
+	orderBy := fmt.Sprintf("j.id %s", dir)
+	if f.Order == JournalOrderDate {
+		orderBy = fmt.Sprintf("j.date %s, j.id %s", dir, dir)
+	}
+	if f.After != nil {
+		if f.Order == JournalOrderDate {
+			where = append(where, fmt.Sprintf("(j.date %s ? or (j.date = ? and j.id %s ?))", cmp, cmp))
+			args = append(args, f.After.Date.UTC(), f.After.Date.UTC(), f.After.Id)
+		} else {
+			where = append(where, fmt.Sprintf("j.id %s ?", cmp))
+			args = append(args, f.After.Id)
+		}
+	}
+
+	return "where " + strings.Join(where, "\nand "), orderBy, args
+}
+
+//queryJournals runs a journal query against a database. q rewrites the ? bind parameters for the database
+func queryJournals(ctx context.Context, db dbtx, q func(string) string, chartId uint64, f JournalFilter) ([]*SplitTransaction, error) {
+	where, orderBy, args := journalFilterSql(chartId, f)
+	query := `
+select j.id, j.note, j.date, j.src, j.ref, coalesce(j.reversalOf, 0), coalesce(j.reversedBy, 0)
+from sa_journal as j
+` + where + "\norder by " + orderBy
+	if f.Limit > 0 {
+		query += fmt.Sprintf("\nlimit %d", f.Limit)
+	}
+	res, err := db.QueryContext(ctx, q(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+	builders := make([]*SplitTransactionBuilder, 0)
+	index := make(map[uint64]int)
+	jrnIds := make([]interface{}, 0)
+	for res.Next() {
+		var id, ref, reversalOf, reversedBy uint64
+		var note, src string
+		var date time.Time
+		if err = res.Scan(&id, &note, &date, &src, &ref, &reversalOf, &reversedBy); err != nil {
+			return nil, err
+		}
+		index[id] = len(builders)
+		jrnIds = append(jrnIds, id)
+		builders = append(builders, NewSplitTransactionBuilder(id).
+			WithNote(note).
+			WithDate(date).
+			WithSource(src).
+			WithReference(ref).
+			WithReversalOf(reversalOf).
+			WithReversedBy(reversedBy))
+	}
+	if err = res.Err(); err != nil {
+		return nil, err
+	}
+	response := make([]*SplitTransaction, 0, len(builders))
+
+	//the entries for all the journals in the page, in batches that keep within the bind parameter limits
+	for start := 0; start < len(jrnIds); start += journalEntryBatch {
+		end := start + journalEntryBatch
+		if end > len(jrnIds) {
+			end = len(jrnIds)
+		}
+		if err = queryJournalEntries(ctx, db, q, jrnIds[start:end], builders, index); err != nil {
+			return nil, err
+		}
+	}
+	for _, builder := range builders {
+		response = append(response, builder.Build())
+	}
+	return response, nil
+}
+
+//journalEntryBatch is the number of journal ids bound in each query for their entries
+const journalEntryBatch = 500
+
+//queryJournalEntries adds the entries of the journals with the ids to their builders, which are found by the index
+func queryJournalEntries(ctx context.Context, db dbtx, q func(string) string, jrnIds []interface{}, builders []*SplitTransactionBuilder, index map[uint64]int) error {
+	in := make([]string, len(jrnIds))
+	for i := range jrnIds {
+		in[i] = "?"
+	}
+	entries, err := db.QueryContext(ctx, q("select e.jrnId, "+entryColumns("e")+" from sa_journal_entry as e where e.jrnId in ("+strings.Join(in, ", ")+") order by e.id"), jrnIds...)
+	if err != nil {
+		return err
+	}
+	defer entries.Close()
+	for entries.Next() {
+		var jrnId uint64
+		var e storedEntry
+		if err = entries.Scan(append([]interface{}{&jrnId}, e.dest()...)...); err != nil {
+			return err
+		}
+		builders[index[jrnId]].WithEntry(*e.entry())
+	}
+	return entries.Err()
+}
@@ -0,0 +1,183 @@
+//go:build unit || integration
+// +build unit integration
+
+package sa_test
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"github.com/chippyash/go-simple-accounts/sa"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestAccountant_QueryJournalsFilters(t *testing.T) {
+	july, _ := time.Parse(time.RFC3339, "2020-07-01T00:00:00Z")
+	endJuly, _ := time.Parse(time.RFC3339, "2020-07-31T23:59:59Z")
+	for name, accountant := range storeTestAccountants(t) {
+		writeQueryTestJournals(t, accountant)
+		for test, tc := range map[string]struct {
+			query    *sa.JournalQuery
+			expected []uint64
+		}{
+			"all in date order":     {sa.NewJournalQuery(), []uint64{5, 1, 2, 3, 4}},
+			"date range":            {sa.NewJournalQuery().Between(july, endJuly), []uint64{1, 2}},
+			"open date range":       {sa.NewJournalQuery().Between(july, time.Time{}), []uint64{1, 2, 3, 4}},
+			"account":               {sa.NewJournalQuery().ForAccount("1210"), []uint64{1, 2, 3}},
+			"accounts":              {sa.NewJournalQuery().ForAccount("6121").ForAccount("6122"), []uint64{2, 4}},
+			"account with children": {sa.NewJournalQuery().ForAccountWithChildren("6120"), []uint64{2, 4}},
+			"parent account":        {sa.NewJournalQuery().ForAccount("6120"), []uint64{}},
+			"source":                {sa.NewJournalQuery().WithSource("PAY"), []uint64{1, 3}},
+			"reference":             {sa.NewJournalQuery().WithReference(4), []uint64{4}},
+			"amount range":          {sa.NewJournalQuery().WithAmountBetween(100, 500), []uint64{5, 2}},
+			"maximum amount":        {sa.NewJournalQuery().WithAmountBetween(0, 100), []uint64{2, 4}},
+			"note text":             {sa.NewJournalQuery().WithNoteContaining("SALARY"), []uint64{1, 3}},
+			"combined":              {sa.NewJournalQuery().WithSource("BANK").WithNoteContaining("garden"), []uint64{2, 4}},
+			"id order":              {sa.NewJournalQuery().OrderBy(sa.JournalOrderId), []uint64{1, 2, 3, 4, 5}},
+			"descending date order": {sa.NewJournalQuery().Descending(), []uint64{4, 3, 2, 1, 5}},
+			"descending id order":   {sa.NewJournalQuery().OrderBy(sa.JournalOrderId).Descending(), []uint64{5, 4, 3, 2, 1}},
+		} {
+			page, err := accountant.QueryJournals(tc.query)
+			assert.NoError(t, err, "%s: %s", name, test)
+			assert.Equal(t, tc.expected, journalIds(page.Journals), "%s: %s", name, test)
+			assert.Equal(t, "", page.Next, "%s: %s", name, test)
+		}
+
+		//full journals are returned
+		page, _ := accountant.QueryJournals(sa.NewJournalQuery().WithReference(2))
+		jrn := page.Journals[0]
+		assert.Equal(t, "BANK", jrn.Src(), name)
+		assert.Equal(t, "Gardener", jrn.Note(), name)
+		assert.Equal(t, 2, len(jrn.Entries()), name)
+		assert.True(t, jrn.CheckBalance(), name)
+
+		_, err := accountant.QueryJournals(sa.NewJournalQuery().ForAccount("9999"))
+		assert.ErrorIs(t, err, sa.ErrBadNominal, name)
+	}
+}
+
+func TestAccountant_QueryJournalsPages(t *testing.T) {
+	for name, accountant := range storeTestAccountants(t) {
+		writeQueryTestJournals(t, accountant)
+		for test, tc := range map[string]struct {
+			query    *sa.JournalQuery
+			expected [][]uint64
+		}{
+			"date order":            {sa.NewJournalQuery().Limit(2), [][]uint64{{5, 1}, {2, 3}, {4}}},
+			"descending date order": {sa.NewJournalQuery().Descending().Limit(2), [][]uint64{{4, 3}, {2, 1}, {5}}},
+			"descending id order":   {sa.NewJournalQuery().OrderBy(sa.JournalOrderId).Descending().Limit(3), [][]uint64{{5, 4, 3}, {2, 1}}},
+			"filtered":              {sa.NewJournalQuery().ForAccountWithChildren("1200").Limit(4), [][]uint64{{5, 1, 2, 3}, {4}}},
+			"exact page":            {sa.NewJournalQuery().Limit(5), [][]uint64{{5, 1, 2, 3, 4}}},
+		} {
+			pages := make([][]uint64, 0)
+			for {
+				page, err := accountant.QueryJournals(tc.query)
+				assert.NoError(t, err, "%s: %s", name, test)
+				pages = append(pages, journalIds(page.Journals))
+				if page.Next == "" || len(pages) > len(tc.expected) {
+					break
+				}
+				tc.query.After(page.Next)
+			}
+			assert.Equal(t, tc.expected, pages, "%s: %s", name, test)
+		}
+
+		_, err := accountant.QueryJournals(sa.NewJournalQuery().After("not a cursor"))
+		assert.ErrorIs(t, err, sa.ErrBadCursor, name)
+	}
+}
+
+func TestAccountant_FindTransactionsBySource(t *testing.T) {
+	july, _ := time.Parse(time.RFC3339, "2020-07-01T00:00:00Z")
+	endJuly, _ := time.Parse(time.RFC3339, "2020-07-31T23:59:59Z")
+	for name, accountant := range storeTestAccountants(t) {
+		writeQueryTestJournals(t, accountant)
+
+		journals, err := accountant.FindTransactionsBySource("PAY", 3)
+		assert.NoError(t, err, name)
+		assert.Equal(t, []uint64{3}, journalIds(journals), name)
+		assert.Equal(t, 2, len(journals[0].Entries()), name)
+		assert.Equal(t, "August salary", journals[0].Note(), name)
+
+		journals, err = accountant.FindTransactionsBySource("PAY", 0)
+		assert.NoError(t, err, name)
+		assert.Equal(t, []uint64{1, 3}, journalIds(journals), name)
+
+		journals, err = accountant.FindTransactionsBySource("PAY", 2)
+		assert.NoError(t, err, name)
+		assert.Equal(t, 0, len(journals), name)
+
+		journals, err = accountant.FindTransactionsBySourceBetween("BANK", july, endJuly)
+		assert.NoError(t, err, name)
+		assert.Equal(t, []uint64{2}, journalIds(journals), name)
+		journals, err = accountant.FindTransactionsBySourceBetween("BANK", july, time.Time{})
+		assert.NoError(t, err, name)
+		assert.Equal(t, []uint64{2, 4}, journalIds(journals), name)
+	}
+}
+
+func TestAccountant_FindTransactionsBySourceManyJournals(t *testing.T) {
+	dt, _ := time.Parse(time.RFC3339, "2020-07-01T12:00:00Z")
+	for name, accountant := range storeTestAccountants(t) {
+		//more journals than are bound in one query for their entries
+		err := accountant.InTx(func(tx *sa.AccountantTx) error {
+			for i := 0; i < 1200; i++ {
+				txn := sa.NewSimpleTransactionBuilder(0, "1210", "4100", 1).WithSource("BULK").Build()
+				if _, err := tx.WriteTransactionWithDate(txn, dt); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		assert.NoError(t, err, name)
+
+		journals, err := accountant.FindTransactionsBySource("BULK", 0)
+		assert.NoError(t, err, name)
+		assert.Equal(t, 1200, len(journals), name)
+		for _, jrn := range journals {
+			assert.Equal(t, 2, len(jrn.Entries()), name)
+		}
+	}
+}
+
+//writeQueryTestJournals writes five journals. Journals 3 and 4 have the same date, and journal 5 is the earliest
+func writeQueryTestJournals(t *testing.T, accountant *sa.Accountant) {
+	for _, posting := range []struct {
+		date   string
+		dr, cr sa.Nominal
+		amount int64
+		src    string
+		ref    uint64
+		note   string
+	}{
+		{"2020-07-01T12:00:00Z", "1210", "4100", 1000, "PAY", 1, "July salary"},
+		{"2020-07-15T12:00:00Z", "6121", "1210", 100, "BANK", 2, "Gardener"},
+		{"2020-08-01T12:00:00Z", "1210", "4100", 1000, "PAY", 3, "August salary"},
+		{"2020-08-01T12:00:00Z", "6122", "1220", 50, "BANK", 4, "Plants for the garden"},
+		{"2020-06-30T12:00:00Z", "1220", "4200", 300, "MISC", 5, "Odd job"},
+	} {
+		dt, _ := time.Parse(time.RFC3339, posting.date)
+		txn := sa.NewSimpleTransactionBuilder(0, posting.dr, posting.cr, posting.amount).
+			WithSource(posting.src).
+			WithReference(posting.ref).
+			WithNote(posting.note).
+			Build()
+		_, err := accountant.WriteTransactionWithDate(txn, dt)
+		assert.NoError(t, err)
+	}
+}
+
+func journalIds(journals []*sa.SplitTransaction) []uint64 {
+	ids := make([]uint64, 0)
+	for _, jrn := range journals {
+		ids = append(ids, jrn.Id())
+	}
+	return ids
+}
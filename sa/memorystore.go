@@ -0,0 +1,694 @@
+package sa
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+)
+
+//MemoryStore is a Store that holds everything in memory.
+//It has no persistence and is intended for unit tests and simulations
+type MemoryStore struct {
+	mu        sync.RWMutex
+	charts    map[uint64]*memChart
+	chartSeq  uint64
+	ledgerSeq uint64
+	jrnSeq    uint64
+	periodSeq uint64
+	//rates are the exchange rates, which are not held by chart
+	rates []ExchangeRate
+	//parent is the store that a transaction copy will be committed to
+	parent *MemoryStore
+	//version is moved on by every write to the store
+	version uint64
+}
+
+//memTxStore is a copy of a MemoryStore that replaces the original on commit.
+//The original store can still be used, but the commit fails if it has been written to since the copy was taken
+type memTxStore struct {
+	*MemoryStore
+	//base is the version of the original store when the copy was taken
+	base uint64
+	done bool
+}
+
+type memChart struct {
+	name     string
+	info     ChartInfo
+	ledgers  map[uint64]*Ledger
+	nominals map[Nominal]uint64
+	journals []*memJournal
+	periods  []Period
+}
+
+type memJournal struct {
+	id         uint64
+	note       string
+	date       time.Time
+	src        string
+	ref        uint64
+	reversalOf uint64
+	reversedBy uint64
+	//keyed is true if src and ref are a unique key for the journal in its chart
+	keyed   bool
+	entries []storedEntry
+}
+
+//NewMemoryStore constructor
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		charts: make(map[uint64]*memChart),
+	}
+}
+
+//Begin returns a copy of the store, which replaces the store on Commit.
+//The store is not locked by the transaction. Commit returns ErrTxConflict if the store has been written to since Begin
+func (s *MemoryStore) Begin(ctx context.Context) (TxStore, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if s.parent != nil {
+		return nil, ErrNestedTransaction
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	cp := &MemoryStore{
+		charts:    make(map[uint64]*memChart, len(s.charts)),
+		chartSeq:  s.chartSeq,
+		ledgerSeq: s.ledgerSeq,
+		jrnSeq:    s.jrnSeq,
+		periodSeq: s.periodSeq,
+		rates:     append([]ExchangeRate(nil), s.rates...),
+		parent:    s,
+	}
+	for id, c := range s.charts {
+		cp.charts[id] = c.clone()
+	}
+	return &memTxStore{MemoryStore: cp, base: s.version}, nil
+}
+
+//Commit replaces the original store contents with those of the transaction
+func (s *memTxStore) Commit() error {
+	if s.done {
+		return sql.ErrTxDone
+	}
+	s.done = true
+	p := s.parent
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.version != s.base {
+		return ErrTxConflict
+	}
+	p.version++
+	p.charts = s.charts
+	p.chartSeq = s.chartSeq
+	p.ledgerSeq = s.ledgerSeq
+	p.jrnSeq = s.jrnSeq
+	p.periodSeq = s.periodSeq
+	p.rates = s.rates
+	return nil
+}
+
+//Rollback abandons the transaction, leaving the original store unchanged
+func (s *memTxStore) Rollback() error {
+	if s.done {
+		return sql.ErrTxDone
+	}
+	s.done = true
+	return nil
+}
+
+func (c *memChart) clone() *memChart {
+	cp := &memChart{
+		name:     c.name,
+		info:     c.info,
+		ledgers:  make(map[uint64]*Ledger, len(c.ledgers)),
+		nominals: make(map[Nominal]uint64, len(c.nominals)),
+		journals: make([]*memJournal, len(c.journals)),
+		periods:  append([]Period(nil), c.periods...),
+	}
+	for id, l := range c.ledgers {
+		ledger := *l
+		cp.ledgers[id] = &ledger
+	}
+	for nom, id := range c.nominals {
+		cp.nominals[nom] = id
+	}
+	for i, j := range c.journals {
+		jrn := *j
+		cp.journals[i] = &jrn
+	}
+	return cp
+}
+
+//SchemaVersion always returns the current schema version, as a MemoryStore has no schema
+func (s *MemoryStore) SchemaVersion(ctx context.Context) (uint, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, false, err
+	}
+	return SchemaVersion, false, nil
+}
+
+//CreateChart stores a new chart and returns its id
+func (s *MemoryStore) CreateChart(ctx context.Context, name string) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version++
+	for _, c := range s.charts {
+		if c.name == name {
+			return 0, ErrChartExists
+		}
+	}
+	s.chartSeq++
+	s.charts[s.chartSeq] = &memChart{
+		name:     name,
+		info:     ChartInfo{Created: time.Now().UTC()},
+		ledgers:  make(map[uint64]*Ledger),
+		nominals: make(map[Nominal]uint64),
+		journals: make([]*memJournal, 0),
+	}
+	return s.chartSeq, nil
+}
+
+//FetchChartName returns the name of a chart
+func (s *MemoryStore) FetchChartName(ctx context.Context, chartId uint64) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.charts[chartId]
+	if !ok {
+		return "", ErrNoChartName
+	}
+	return c.name, nil
+}
+
+//FetchChartInfo returns the name and metadata of a chart
+func (s *MemoryStore) FetchChartInfo(ctx context.Context, chartId uint64) (*ChartInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.charts[chartId]
+	if !ok {
+		return nil, ErrNoChartName
+	}
+	info := c.info
+	info.Id = chartId
+	info.Name = c.name
+	return &info, nil
+}
+
+//SetChartInfo sets the currency, description and fiscal year start of a chart
+func (s *MemoryStore) SetChartInfo(ctx context.Context, chartId uint64, info ChartInfo) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version++
+	c, ok := s.charts[chartId]
+	if !ok {
+		return ErrChartNotFound
+	}
+	c.info.Crcy = info.Crcy
+	c.info.Description = info.Description
+	c.info.FiscalYearStart = info.FiscalYearStart.UTC()
+	return nil
+}
+
+//ListCharts returns the name and metadata of every chart, ordered by id
+func (s *MemoryStore) ListCharts(ctx context.Context) ([]ChartInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	charts := make([]ChartInfo, 0, len(s.charts))
+	for id, c := range s.charts {
+		info := c.info
+		info.Id = id
+		info.Name = c.name
+		charts = append(charts, info)
+	}
+	sort.Slice(charts, func(i, j int) bool {
+		return charts[i].Id < charts[j].Id
+	})
+	return charts, nil
+}
+
+//RenameChart renames a chart
+func (s *MemoryStore) RenameChart(ctx context.Context, chartId uint64, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version++
+	c, ok := s.charts[chartId]
+	if !ok {
+		return ErrChartNotFound
+	}
+	for id, other := range s.charts {
+		if id != chartId && other.name == name {
+			return ErrChartExists
+		}
+	}
+	c.name = name
+	return nil
+}
+
+//DeleteChart deletes a chart with its ledgers, journals and periods
+func (s *MemoryStore) DeleteChart(ctx context.Context, chartId uint64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version++
+	if _, ok := s.charts[chartId]; !ok {
+		return ErrChartNotFound
+	}
+	delete(s.charts, chartId)
+	return nil
+}
+
+//AddLedger adds a ledger to a chart
+func (s *MemoryStore) AddLedger(ctx context.Context, chartId uint64, nominal Nominal, tpe *AccountType, name string, prnt Nominal) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	acType, ok := GetValuedAccountTypes()[*tpe]
+	if !ok {
+		return ErrBadAccountType
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version++
+	c, ok := s.charts[chartId]
+	if !ok {
+		return ErrChartNotFound
+	}
+	if _, ok := c.nominals[nominal]; ok {
+		return ErrNominalExists
+	}
+	var prntId uint64
+	if prnt == "" {
+		for _, l := range c.ledgers {
+			if l.PrntId == 0 {
+				return ErrRootExists
+			}
+		}
+	} else {
+		prntId, ok = c.nominals[prnt]
+		if !ok {
+			return ErrBadParent
+		}
+	}
+	s.ledgerSeq++
+	c.ledgers[s.ledgerSeq] = &Ledger{
+		PrntId:  prntId,
+		Id:      s.ledgerSeq,
+		Nominal: nominal,
+		Name:    name,
+		Tpe:     acType,
+		ChartId: chartId,
+	}
+	c.nominals[nominal] = s.ledgerSeq
+	return nil
+}
+
+//DelLedger deletes a ledger and all of its descendant ledgers
+func (s *MemoryStore) DelLedger(ctx context.Context, chartId uint64, nominal Nominal) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version++
+	c, ok := s.charts[chartId]
+	if !ok {
+		return ErrChartNotFound
+	}
+	id, ok := c.nominals[nominal]
+	if !ok {
+		return nil
+	}
+	l := c.ledgers[id]
+	if l.AcDr > 0 || l.AcCr > 0 {
+		return ErrNonZeroBalance
+	}
+	c.delete(id)
+	return nil
+}
+
+func (c *memChart) delete(id uint64) {
+	for childId, l := range c.ledgers {
+		if l.PrntId == id {
+			c.delete(childId)
+		}
+	}
+	delete(c.nominals, c.ledgers[id].Nominal)
+	delete(c.ledgers, id)
+}
+
+//FetchLedgers returns the ledgers of a chart
+func (s *MemoryStore) FetchLedgers(ctx context.Context, chartId uint64) (Ledgers, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.charts[chartId]
+	if !ok {
+		return Ledgers{}, nil
+	}
+	ledgers := make(Ledgers, 0, len(c.ledgers))
+	for _, l := range c.ledgers {
+		ledgers = append(ledgers, *l)
+	}
+	sort.Slice(ledgers, func(i, j int) bool {
+		if ledgers[i].PrntId == ledgers[j].PrntId {
+			return ledgers[i].Id < ledgers[j].Id
+		}
+		return ledgers[i].PrntId < ledgers[j].PrntId
+	})
+	return ledgers, nil
+}
+
+//WriteJournal stores a journal and rolls each entry up the ledger parent chain,
+//in the same way as the sp_tr_jrn_entry_updt trigger. A reversal journal voids the journal that it reverses
+func (s *MemoryStore) WriteJournal(ctx context.Context, chartId uint64, txn *SplitTransaction, dt time.Time) (uint64, error) {
+	return s.writeJournal(ctx, chartId, txn, dt, false)
+}
+
+//WriteKeyedJournal stores a journal with its src and ref as a key that is unique within the chart
+func (s *MemoryStore) WriteKeyedJournal(ctx context.Context, chartId uint64, txn *SplitTransaction, dt time.Time) (uint64, error) {
+	return s.writeJournal(ctx, chartId, txn, dt, true)
+}
+
+func (s *MemoryStore) writeJournal(ctx context.Context, chartId uint64, txn *SplitTransaction, dt time.Time, keyed bool) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version++
+	c, ok := s.charts[chartId]
+	if !ok {
+		return 0, ErrChartNotFound
+	}
+	if keyed {
+		for _, jrn := range c.journals {
+			if jrn.keyed && jrn.src == txn.Src() && jrn.ref == txn.Ref() {
+				return 0, ErrDuplicateJournal
+			}
+		}
+	}
+	var reversed *memJournal
+	if txn.ReversalOf() != 0 {
+		reversed = c.journal(txn.ReversalOf())
+		if reversed == nil || reversed.reversedBy != 0 {
+			return 0, ErrJournalVoid
+		}
+	}
+	s.jrnSeq++
+	jrn := &memJournal{
+		id:         s.jrnSeq,
+		note:       txn.Note(),
+		date:       dt.UTC(),
+		src:        txn.Src(),
+		ref:        txn.Ref(),
+		reversalOf: txn.ReversalOf(),
+		keyed:      keyed,
+		entries:    make([]storedEntry, 0, len(txn.Entries())),
+	}
+	if reversed != nil {
+		reversed.reversedBy = jrn.id
+	}
+	for _, entry := range txn.Entries() {
+		e := newStoredEntry(*entry)
+		jrn.entries = append(jrn.entries, e)
+		c.rollUp(e)
+	}
+	c.journals = append(c.journals, jrn)
+
+	return jrn.id, nil
+}
+
+func (c *memChart) rollUp(e storedEntry) {
+	id, ok := c.nominals[e.nominal]
+	for ok {
+		l := c.ledgers[id]
+		l.AcDr += e.acDr
+		l.AcCr += e.acCr
+		id = l.PrntId
+		_, ok = c.ledgers[id]
+	}
+}
+
+//FetchJournal returns a journal and all of its entries
+func (s *MemoryStore) FetchJournal(ctx context.Context, chartId, jrnId uint64) (*SplitTransaction, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.charts[chartId]
+	if !ok {
+		return nil, ErrJournalNotFound
+	}
+	jrn := c.journal(jrnId)
+	if jrn == nil {
+		return nil, ErrJournalNotFound
+	}
+	journal := jrn.builder()
+	for _, e := range jrn.entries {
+		journal = journal.WithEntry(*e.entry())
+	}
+	return journal.Build(), nil
+}
+
+func (c *memChart) journal(jrnId uint64) *memJournal {
+	for _, jrn := range c.journals {
+		if jrn.id == jrnId {
+			return jrn
+		}
+	}
+	return nil
+}
+
+//FetchAccountJournals returns the journals for a ledger
+func (s *MemoryStore) FetchAccountJournals(ctx context.Context, chartId uint64, nominal Nominal) ([]*SplitTransaction, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	response := make([]*SplitTransaction, 0)
+	c, ok := s.charts[chartId]
+	if !ok {
+		return response, nil
+	}
+	for _, jrn := range c.journals {
+		for _, e := range jrn.entries {
+			if e.nominal == nominal {
+				response = append(response, jrn.builder().
+					WithEntry(*e.entry()).
+					Build())
+			}
+		}
+	}
+	return response, nil
+}
+
+//QueryJournals returns the journals selected by the filter, with all their entries
+func (s *MemoryStore) QueryJournals(ctx context.Context, chartId uint64, filter JournalFilter) ([]*SplitTransaction, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.charts[chartId]
+	if !ok {
+		return make([]*SplitTransaction, 0), nil
+	}
+	journals := make([]*SplitTransaction, 0)
+	for _, jrn := range c.journals {
+		journal := jrn.builder()
+		for _, e := range jrn.entries {
+			journal = journal.WithEntry(*e.entry())
+		}
+		if txn := journal.Build(); filter.matches(txn) {
+			journals = append(journals, txn)
+		}
+	}
+	return filter.page(journals), nil
+}
+
+//IterateJournals returns an iterator over the journals selected by the filter
+func (s *MemoryStore) IterateJournals(ctx context.Context, chartId uint64, filter JournalFilter) (JournalIterator, error) {
+	journals, err := s.QueryJournals(ctx, chartId, filter)
+	if err != nil {
+		return nil, err
+	}
+	return &sliceJournalIterator{journals: journals}, nil
+}
+
+//AddPeriods adds accounting periods to a chart and returns them with their ids
+func (s *MemoryStore) AddPeriods(ctx context.Context, chartId uint64, periods []Period) ([]Period, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version++
+	c, ok := s.charts[chartId]
+	if !ok {
+		return nil, ErrChartNotFound
+	}
+	added := make([]Period, len(periods))
+	for i, p := range periods {
+		s.periodSeq++
+		p.Id = s.periodSeq
+		p.Start, p.End = p.Start.UTC(), p.End.UTC()
+		added[i] = p
+	}
+	c.periods = append(c.periods, added...)
+	sortPeriods(c.periods)
+	return added, nil
+}
+
+//FetchPeriods returns the accounting periods of a chart in date order
+func (s *MemoryStore) FetchPeriods(ctx context.Context, chartId uint64) ([]Period, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	periods := make([]Period, 0)
+	if c, ok := s.charts[chartId]; ok {
+		periods = append(periods, c.periods...)
+	}
+	return periods, nil
+}
+
+//SetPeriodStatus sets the status of an accounting period
+func (s *MemoryStore) SetPeriodStatus(ctx context.Context, chartId, periodId uint64, status PeriodStatus) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version++
+	if c, ok := s.charts[chartId]; ok {
+		for i := range c.periods {
+			if c.periods[i].Id == periodId {
+				c.periods[i].Status = status
+				return nil
+			}
+		}
+	}
+	return ErrPeriodNotFound
+}
+
+//AddExchangeRates stores exchange rates, replacing any rate for the same currency pair and date
+func (s *MemoryStore) AddExchangeRates(ctx context.Context, rates []ExchangeRate) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version++
+	for _, rate := range rates {
+		rate.Date = rate.Date.UTC()
+		replaced := false
+		for i, r := range s.rates {
+			if r.From == rate.From && r.To == rate.To && r.Date.Equal(rate.Date) {
+				s.rates[i] = rate
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			s.rates = append(s.rates, rate)
+		}
+	}
+	return nil
+}
+
+//FetchExchangeRate returns the latest rate for the currency pair dated on or before dt
+func (s *MemoryStore) FetchExchangeRate(ctx context.Context, from, to string, dt time.Time) (*ExchangeRate, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var latest *ExchangeRate
+	for i, r := range s.rates {
+		if r.From == from && r.To == to && !r.Date.After(dt) && (latest == nil || r.Date.After(latest.Date)) {
+			latest = &s.rates[i]
+		}
+	}
+	if latest == nil {
+		return nil, ErrNoExchangeRate
+	}
+	rate := *latest
+	return &rate, nil
+}
+
+//FetchLedgerTotals returns the sums of the journal entries for each ledger, for journals dated between from and to
+func (s *MemoryStore) FetchLedgerTotals(ctx context.Context, chartId uint64, from, to time.Time) ([]LedgerTotal, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	totals := make([]LedgerTotal, 0)
+	c, ok := s.charts[chartId]
+	if !ok {
+		return totals, nil
+	}
+	index := make(map[Nominal]int)
+	for _, jrn := range c.journals {
+		if jrn.date.After(to) || (!from.IsZero() && jrn.date.Before(from)) {
+			continue
+		}
+		for _, e := range jrn.entries {
+			i, ok := index[e.nominal]
+			if !ok {
+				i = len(totals)
+				index[e.nominal] = i
+				totals = append(totals, LedgerTotal{Nominal: e.nominal})
+			}
+			totals[i].AcDr += e.acDr
+			totals[i].AcCr += e.acCr
+		}
+	}
+	return totals, nil
+}
+
+func (j *memJournal) builder() *SplitTransactionBuilder {
+	return NewSplitTransactionBuilder(j.id).
+		WithDate(j.date).
+		WithNote(j.note).
+		WithSource(j.src).
+		WithReference(j.ref).
+		WithReversalOf(j.reversalOf).
+		WithReversedBy(j.reversedBy)
+}
@@ -0,0 +1,251 @@
+//go:build unit
+// +build unit
+
+package sa_test
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"context"
+	"github.com/chippyash/go-simple-accounts/sa"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var memAccountant *sa.Accountant
+
+func TestMemoryStore_CreateChart(t *testing.T) {
+	setupMemoryStoreTest(t)
+	chart, err := memAccountant.FetchChart()
+	assert.NoError(t, err)
+	assert.Equal(t, "Test", chart.Name())
+	assert.Equal(t, 5, chart.Tree().GetHeight())
+	assert.Equal(t, sa.NewAcType().Real(), chart.Tree().GetValue().(*sa.Account).Type())
+	assert.Equal(t, sa.Nominal("0001"), chart.GetParentId(sa.MustNewNominal("1000")))
+}
+
+func TestMemoryStore_ChartNamesAreUnique(t *testing.T) {
+	store := sa.NewMemoryStore()
+	_, err := store.CreateChart(context.Background(), "Test")
+	assert.NoError(t, err)
+	_, err = store.CreateChart(context.Background(), "Test")
+	assert.ErrorIs(t, err, sa.ErrChartExists)
+}
+
+func TestMemoryStore_CanBeReadDuringTransaction(t *testing.T) {
+	dt, _ := time.Parse(time.RFC3339, "2021-01-31T12:00:00Z")
+	store := sa.NewMemoryStore()
+	accountant := sa.NewAccountant(store, 0, "GBP").WithExchangeRateProvider(sa.NewStoreRateProvider(store).WithPivot("EUR"))
+	def, _ := sa.NewChartDefinition("../tests/_data/personal.xml")
+	_, err := accountant.CreateChart("Test", "GBP", def)
+	assert.NoError(t, err)
+	assert.NoError(t, accountant.AddExchangeRates([]sa.ExchangeRate{
+		{From: "EUR", To: "GBP", Date: dt, Rate: 0.8},
+		{From: "EUR", To: "USD", Date: dt, Rate: 1.25},
+	}))
+	txn := sa.NewSplitTransactionBuilder(0).
+		WithEntry(*sa.NewCurrencyEntry("1220", 8000, *sa.NewAcType().Dr(), "USD", 10000, 0.8)).
+		WithEntry(*sa.NewEntry("4100", 8000, *sa.NewAcType().Cr())).
+		Build()
+	_, err = accountant.WriteTransactionWithDate(txn, dt)
+	assert.NoError(t, err)
+
+	//the revaluation runs in a transaction, and its rate provider reads the rates from the store
+	done := make(chan error)
+	go func() {
+		_, err := accountant.Revalue(dt, map[string]float64{}, "4200")
+		done <- err
+	}()
+	select {
+	case err = <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("revaluation blocked on the store")
+	}
+	balance, err := accountant.BalanceAsAt("1220", dt)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6400), balance)
+}
+
+func TestMemoryStore_CommitConflict(t *testing.T) {
+	ctx := context.Background()
+	store := sa.NewMemoryStore()
+	tx, err := store.Begin(ctx)
+	assert.NoError(t, err)
+	_, err = tx.CreateChart(ctx, "Transaction")
+	assert.NoError(t, err)
+	_, err = store.CreateChart(ctx, "Store")
+	assert.NoError(t, err)
+	assert.ErrorIs(t, tx.Commit(), sa.ErrTxConflict)
+	charts, _ := store.ListCharts(ctx)
+	if assert.Equal(t, 1, len(charts)) {
+		assert.Equal(t, "Store", charts[0].Name)
+	}
+}
+
+func TestMemoryStore_WriteTransactionRollsUpParentLedgers(t *testing.T) {
+	setupMemoryStoreTest(t)
+	dt, _ := time.Parse(time.RFC3339, "2020-08-05T14:36:00+01:00")
+	txn := sa.NewSimpleTransactionBuilder(0, "1210", "4100", 100).Build()
+	_, err := memAccountant.WriteTransactionWithDate(txn, dt)
+	assert.NoError(t, err)
+	txn = sa.NewSimpleTransactionBuilder(0, "6120", "1210", 10).Build()
+	_, err = memAccountant.WriteTransactionWithDate(txn, dt)
+	assert.NoError(t, err)
+
+	values := map[string][]int64{
+		"0000": {110, 110},
+		"0001": {100, 10},
+		"1000": {100, 10},
+		"1100": {100, 10},
+		"1200": {100, 10},
+		"1210": {100, 10},
+		"1220": {0, 0},
+		"0002": {10, 100},
+		"4000": {0, 100},
+		"4100": {0, 100},
+		"6000": {10, 0},
+		"6100": {10, 0},
+		"6120": {10, 0},
+		"6121": {0, 0},
+	}
+	chart, _ := memAccountant.FetchChart()
+	for nom, vals := range values {
+		ac := chart.GetAccount(sa.MustNewNominal(nom))
+		assert.Equal(t, vals[0], ac.Dr(), "DR a/c value: %d not equal %d for Nominal: %s", ac.Dr(), vals[0], nom)
+		assert.Equal(t, vals[1], ac.Cr(), "CR a/c value: %d not equal %d for Nominal: %s", ac.Cr(), vals[1], nom)
+	}
+}
+
+func TestMemoryStore_WriteUnbalancedTransaction(t *testing.T) {
+	setupMemoryStoreTest(t)
+	entry := sa.NewEntry("0001", 100, *sa.NewAcType().Dr())
+	txn := sa.NewSplitTransactionBuilder(0).
+		WithEntries(sa.Entries{entry, entry}).
+		Build()
+	_, err := memAccountant.WriteTransaction(txn)
+	assert.ErrorIs(t, err, sa.ErrUnbalancedTransaction)
+}
+
+func TestMemoryStore_CancelledContext(t *testing.T) {
+	setupMemoryStoreTest(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	txn := sa.NewSimpleTransactionBuilder(0, "1210", "4100", 100).Build()
+	_, err := memAccountant.WriteTransactionContext(ctx, txn)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = memAccountant.FetchChartContext(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestMemoryStore_FetchTransaction(t *testing.T) {
+	setupMemoryStoreTest(t)
+	dt, _ := time.Parse(time.RFC3339, "2020-08-05T14:36:00+01:00")
+	txn := sa.NewSimpleTransactionBuilder(0, "1210", "4100", 100).
+		WithNote("foo").
+		WithSource("PAY").
+		WithReference(2).
+		Build()
+	jrnId, _ := memAccountant.WriteTransactionWithDate(txn, dt)
+
+	journal, err := memAccountant.FetchTransaction(jrnId)
+	assert.NoError(t, err)
+	assert.Equal(t, jrnId, journal.Id())
+	assert.Equal(t, "2020-08-05T13:36:00Z", journal.Date().Format(time.RFC3339))
+	assert.Equal(t, "foo", journal.Note())
+	assert.Equal(t, "PAY", journal.Src())
+	assert.Equal(t, uint64(2), journal.Ref())
+	assert.Equal(t, 2, len(journal.Entries()))
+	assert.True(t, journal.CheckBalance())
+
+	_, err = memAccountant.FetchTransaction(jrnId + 1)
+	assert.ErrorIs(t, err, sa.ErrJournalNotFound)
+}
+
+func TestMemoryStore_FetchAccountJournals(t *testing.T) {
+	setupMemoryStoreTest(t)
+	txn := sa.NewSimpleTransactionBuilder(0, "1210", "4100", 100).Build()
+	_, _ = memAccountant.WriteTransaction(txn)
+	txn = sa.NewSimpleTransactionBuilder(0, "6120", "1210", 10).Build()
+	_, _ = memAccountant.WriteTransaction(txn)
+
+	journals, err := memAccountant.FetchAccountJournals("1210")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(journals))
+	assert.Equal(t, 1, len(journals[0].Entries()))
+	assert.Equal(t, 1, len(journals[1].Entries()))
+	assert.Equal(t, []*sa.Nominal{journals[1].Entries()[0].Id()}, journals[1].GetCrAc())
+}
+
+func TestMemoryStore_AddAccount(t *testing.T) {
+	setupMemoryStoreTest(t)
+
+	//2nd root account - error
+	nom := sa.MustNewNominal("1111")
+	err := memAccountant.AddAccount(nom, sa.NewAcType().Asset(), "foo", nil)
+	assert.ErrorIs(t, err, sa.ErrRootExists)
+
+	//parent doesn't exist - error
+	prnt := sa.MustNewNominal("9999")
+	err = memAccountant.AddAccount(nom, sa.NewAcType().Asset(), "foo", &prnt)
+	assert.ErrorIs(t, err, sa.ErrBadParent)
+
+	//valid insertion
+	prnt = sa.MustNewNominal("0000")
+	err = memAccountant.AddAccount(nom, sa.NewAcType().Asset(), "foo", &prnt)
+	assert.NoError(t, err)
+
+	//duplicate nominal - error
+	err = memAccountant.AddAccount(nom, sa.NewAcType().Asset(), "foo", &prnt)
+	assert.ErrorIs(t, err, sa.ErrNominalExists)
+}
+
+func TestMemoryStore_DelAccount(t *testing.T) {
+	setupMemoryStoreTest(t)
+	txn := sa.NewSimpleTransactionBuilder(0, "1210", "4100", 100).Build()
+	_, _ = memAccountant.WriteTransaction(txn)
+
+	//attempt to delete account with non-zero balance - error
+	err := memAccountant.DelAccount(sa.MustNewNominal("1200"))
+	assert.ErrorIs(t, err, sa.ErrNonZeroBalance)
+
+	//zero balance - ok, and child accounts are deleted
+	err = memAccountant.DelAccount(sa.MustNewNominal("6100"))
+	assert.NoError(t, err)
+	chart, _ := memAccountant.FetchChart()
+	assert.False(t, chart.HasAccount(sa.MustNewNominal("6100")))
+	assert.False(t, chart.HasAccount(sa.MustNewNominal("6123")))
+	assert.True(t, chart.HasAccount(sa.MustNewNominal("6200")))
+}
+
+func TestMemoryStore_NextNominal(t *testing.T) {
+	setupMemoryStoreTest(t)
+	starter := sa.MustNewNominal("1801")
+	prnt := sa.MustNewNominal("1800")
+
+	next, err := memAccountant.NextNominal(prnt, starter)
+	assert.NoError(t, err)
+	assert.Equal(t, "1801", next.String())
+
+	err = memAccountant.AddAccount(starter, sa.NewAcType().Asset(), "Tractor", &prnt)
+	assert.NoError(t, err)
+
+	next, err = memAccountant.NextNominal(prnt, starter)
+	assert.NoError(t, err)
+	assert.Equal(t, "1802", next.String())
+}
+
+func setupMemoryStoreTest(t *testing.T) {
+	memAccountant = sa.NewAccountant(sa.NewMemoryStore(), 0, "GBP")
+	def, err := sa.NewChartDefinition("../tests/_data/personal.xml")
+	assert.NoError(t, err)
+	_, err = memAccountant.CreateChart("Test", "GBP", def)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,269 @@
+package sa
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/chippyash/go-simple-accounts/db"
+	"github.com/go-sql-driver/mysql"
+	"io/fs"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+//SchemaVersion is the database schema version that this library works with
+const SchemaVersion uint = 8
+
+//Migration is the status of a schema migration
+type Migration struct {
+	Version uint
+	Name    string
+	Applied bool
+	//Dirty is true if the migration failed part way through. The schema must be repaired by hand
+	Dirty bool
+}
+
+//migration is an embedded migration script pair
+type migration struct {
+	version  uint
+	name     string
+	up, down string
+}
+
+//migrationFile matches the golang-migrate file naming convention
+var migrationFile = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+//The version table is compatible with golang-migrate, so a database built with the migrate
+//command line tool can be carried on with Migrate, and vice versa
+const (
+	sqlVersionTable  = "create table if not exists schema_migrations (version bigint not null primary key, dirty boolean not null)"
+	sqlVersionSelect = "select version, dirty from schema_migrations"
+	sqlVersionDelete = "delete from schema_migrations"
+	sqlVersionInsert = "insert into schema_migrations (version, dirty) values (%d, %t)"
+)
+
+//migrationDirs are the directories in db.Migrations that hold the migrations for each database driver, by driver type
+var (
+	migrationDirsMu sync.RWMutex
+	migrationDirs   = map[reflect.Type]string{
+		reflect.TypeOf(&mysql.MySQLDriver{}): "migrations",
+	}
+)
+
+//RegisterMigrations sets the directory in db.Migrations that holds the migrations for databases opened with
+//the driver. The sa/sqlite and sa/postgres packages register their drivers when they are imported
+func RegisterMigrations(drv driver.Driver, dir string) {
+	migrationDirsMu.Lock()
+	defer migrationDirsMu.Unlock()
+	migrationDirs[reflect.TypeOf(drv)] = dir
+}
+
+//Migrate applies all outstanding migrations to the database.
+//The migrations for the database variant are chosen by the driver that db was opened with.
+//ErrUnknownDriver is returned for a SQLite or PostgreSQL database unless the sa/sqlite or sa/postgres package is imported.
+//A MySql database must be opened with multiStatements=true in its DSN
+func Migrate(db *sql.DB) error {
+	return MigrateToContext(context.Background(), db, SchemaVersion)
+}
+
+//MigrateContext applies all outstanding migrations to the database
+func MigrateContext(ctx context.Context, db *sql.DB) error {
+	return MigrateToContext(ctx, db, SchemaVersion)
+}
+
+//MigrateTo applies the up, or down, migrations needed to bring the database to the given version.
+//Version 0 removes the schema
+func MigrateTo(db *sql.DB, version uint) error {
+	return MigrateToContext(context.Background(), db, version)
+}
+
+//MigrateToContext applies the up, or down, migrations needed to bring the database to the given version.
+//Version 0 removes the schema
+func MigrateToContext(ctx context.Context, db *sql.DB, version uint) error {
+	migrations, err := loadMigrations(db)
+	if err != nil {
+		return err
+	}
+	if version != 0 && migrationIndex(migrations, version) < 0 {
+		return fmt.Errorf("%w: no migration for version %d", ErrSchemaVersion, version)
+	}
+	if _, err = db.ExecContext(ctx, sqlVersionTable); err != nil {
+		return err
+	}
+	current, dirty, err := readSchemaVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return ErrDirtySchema
+	}
+	if current != 0 && migrationIndex(migrations, current) < 0 {
+		return fmt.Errorf("%w: database is at unknown version %d", ErrSchemaVersion, current)
+	}
+
+	//up
+	for _, m := range migrations {
+		if m.version <= current || m.version > version {
+			continue
+		}
+		if err = runMigration(ctx, db, m.version, m.up, m.version); err != nil {
+			return fmt.Errorf("migration %d_%s up: %w", m.version, m.name, err)
+		}
+	}
+
+	//down
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if m.version > current || m.version <= version {
+			continue
+		}
+		var prev uint
+		if i > 0 {
+			prev = migrations[i-1].version
+		}
+		if err = runMigration(ctx, db, m.version, m.down, prev); err != nil {
+			return fmt.Errorf("migration %d_%s down: %w", m.version, m.name, err)
+		}
+	}
+
+	return nil
+}
+
+//MigrationStatus returns the embedded migrations for the database variant, and whether each has been applied
+func MigrationStatus(db *sql.DB) ([]Migration, error) {
+	return MigrationStatusContext(context.Background(), db)
+}
+
+//MigrationStatusContext returns the embedded migrations for the database variant, and whether each has been applied
+func MigrationStatusContext(ctx context.Context, db *sql.DB) ([]Migration, error) {
+	migrations, err := loadMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = db.ExecContext(ctx, sqlVersionTable); err != nil {
+		return nil, err
+	}
+	current, dirty, err := readSchemaVersion(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	status := make([]Migration, len(migrations))
+	for i, m := range migrations {
+		status[i] = Migration{
+			Version: m.version,
+			Name:    m.name,
+			Applied: m.version <= current,
+			Dirty:   dirty && m.version == current,
+		}
+	}
+	return status, nil
+}
+
+//runMigration marks the database as dirty at version, runs the script and then marks the database as clean at next.
+//If the script fails the database is left dirty
+func runMigration(ctx context.Context, db *sql.DB, version uint, script string, next uint) error {
+	if err := writeSchemaVersion(ctx, db, version, true); err != nil {
+		return err
+	}
+	if _, err := db.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	return writeSchemaVersion(ctx, db, next, false)
+}
+
+//readSchemaVersion returns the version of the database schema, and whether the last migration failed
+func readSchemaVersion(ctx context.Context, db dbtx) (uint, bool, error) {
+	var version uint64
+	var dirty bool
+	err := db.QueryRowContext(ctx, sqlVersionSelect).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	return uint(version), dirty, err
+}
+
+func writeSchemaVersion(ctx context.Context, db *sql.DB, version uint, dirty bool) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	if _, err = tx.ExecContext(ctx, sqlVersionDelete); err != nil {
+		return err
+	}
+	if version > 0 {
+		if _, err = tx.ExecContext(ctx, fmt.Sprintf(sqlVersionInsert, version, dirty)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+//loadMigrations returns the embedded migrations for the database variant, in version order
+func loadMigrations(sdb *sql.DB) ([]migration, error) {
+	migrationDirsMu.RLock()
+	dir, ok := migrationDirs[reflect.TypeOf(sdb.Driver())]
+	migrationDirsMu.RUnlock()
+	if !ok {
+		return nil, ErrUnknownDriver
+	}
+	files, err := fs.ReadDir(db.Migrations, dir)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[uint]*migration)
+	for _, f := range files {
+		parts := migrationFile.FindStringSubmatch(f.Name())
+		if parts == nil {
+			continue
+		}
+		version, _ := strconv.ParseUint(parts[1], 10, 64)
+		script, err := fs.ReadFile(db.Migrations, path.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[uint(version)]
+		if !ok {
+			m = &migration{version: uint(version), name: parts[2]}
+			byVersion[m.version] = m
+		}
+		if parts[3] == "up" {
+			m.up = string(script)
+		} else {
+			m.down = string(script)
+		}
+	}
+	migrations := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+	return migrations, nil
+}
+
+func migrationIndex(migrations []migration, version uint) int {
+	for i, m := range migrations {
+		if m.version == version {
+			return i
+		}
+	}
+	return -1
+}
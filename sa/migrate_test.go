@@ -0,0 +1,118 @@
+//go:build unit
+// +build unit
+
+package sa_test
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"database/sql"
+	"fmt"
+	sadb "github.com/chippyash/go-simple-accounts/db"
+	"github.com/chippyash/go-simple-accounts/sa"
+	"github.com/chippyash/go-simple-accounts/sa/sqlite"
+	"github.com/stretchr/testify/assert"
+	"io/fs"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrate_EachDatabaseHasMigrationsUpToSchemaVersion(t *testing.T) {
+	for _, dir := range []string{"migrations", "postgres", "sqlite"} {
+		for v := uint(1); v <= sa.SchemaVersion; v++ {
+			for _, direction := range []string{"up", "down"} {
+				files, err := fs.Glob(sadb.Migrations, fmt.Sprintf("%s/%06d_*.%s.sql", dir, v, direction))
+				assert.NoError(t, err)
+				assert.Equal(t, 1, len(files), "%s has no %s migration for version %d", dir, direction, v)
+			}
+		}
+	}
+}
+
+func TestMigrate_AppliesAndRollsBackMigrations(t *testing.T) {
+	dba := openMigrateTestDb(t)
+
+	status, err := sa.MigrationStatus(dba)
+	assert.NoError(t, err)
+	assert.Equal(t, int(sa.SchemaVersion), len(status))
+	for _, m := range status {
+		assert.False(t, m.Applied)
+	}
+
+	assert.NoError(t, sa.Migrate(dba))
+	status, err = sa.MigrationStatus(dba)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), status[0].Version)
+	assert.Equal(t, "initial_build", status[0].Name)
+	for _, m := range status {
+		assert.True(t, m.Applied)
+		assert.False(t, m.Dirty)
+	}
+	//running again is a no-op
+	assert.NoError(t, sa.Migrate(dba))
+
+	assert.NoError(t, sa.MigrateTo(dba, 0))
+	status, err = sa.MigrationStatus(dba)
+	assert.NoError(t, err)
+	for _, m := range status {
+		assert.False(t, m.Applied)
+	}
+	_, err = dba.Exec("select count(*) from sa_coa")
+	assert.Error(t, err)
+
+	err = sa.MigrateTo(dba, sa.SchemaVersion+1)
+	assert.ErrorIs(t, err, sa.ErrSchemaVersion)
+}
+
+func TestMigrate_RefusesDirtyDatabase(t *testing.T) {
+	dba := openMigrateTestDb(t)
+	assert.NoError(t, sa.Migrate(dba))
+	_, err := dba.Exec("update schema_migrations set dirty = true")
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, sa.MigrateTo(dba, 0), sa.ErrDirtySchema)
+	status, err := sa.MigrationStatus(dba)
+	assert.NoError(t, err)
+	assert.True(t, status[len(status)-1].Dirty)
+
+	accountant := sa.NewAccountant(sqlite.NewStore(dba), 0, "GBP")
+	def, _ := sa.NewChartDefinition("../tests/_data/personal.xml")
+	_, err = accountant.CreateChart("Test", "GBP", def)
+	assert.ErrorIs(t, err, sa.ErrDirtySchema)
+}
+
+func TestMigrate_AccountantRefusesUnknownSchemaVersion(t *testing.T) {
+	dba := openMigrateTestDb(t)
+	accountant := sa.NewAccountant(sqlite.NewStore(dba), 1, "GBP")
+
+	//no schema
+	_, err := accountant.FetchChart()
+	assert.ErrorIs(t, err, sa.ErrSchemaVersion)
+
+	//schema from a later version of the library
+	assert.NoError(t, sa.Migrate(dba))
+	_, err = dba.Exec("update schema_migrations set version = version + 1")
+	assert.NoError(t, err)
+	_, err = accountant.FetchChart()
+	assert.ErrorIs(t, err, sa.ErrSchemaVersion)
+	assert.ErrorIs(t, sa.Migrate(dba), sa.ErrSchemaVersion)
+
+	//current schema
+	_, err = dba.Exec("update schema_migrations set version = version - 1")
+	assert.NoError(t, err)
+	_, err = accountant.FetchChart()
+	assert.ErrorIs(t, err, sa.ErrNoChartLedgers)
+}
+
+func openMigrateTestDb(t *testing.T) *sql.DB {
+	dba, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "sa.db")+"?_foreign_keys=1")
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = dba.Close() })
+	return dba
+}
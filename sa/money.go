@@ -0,0 +1,168 @@
+package sa
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+//minorUnits are the ISO-4217 minor unit exponents of the currencies that do not have two decimal places
+var minorUnits = map[string]int{
+	"BHD": 3, "BIF": 0, "CLF": 4, "CLP": 0, "DJF": 0, "GNF": 0, "IQD": 3, "ISK": 0,
+	"JOD": 3, "JPY": 0, "KMF": 0, "KRW": 0, "KWD": 3, "LYD": 3, "OMR": 3, "PYG": 0,
+	"RWF": 0, "TND": 3, "UGX": 0, "UYI": 0, "UYW": 4, "VND": 0, "VUV": 0, "XAF": 0,
+	"XOF": 0, "XPF": 0,
+}
+
+//CurrencyExponent returns the ISO-4217 minor unit exponent of a currency, e.g. 2 for GBP and 0 for JPY.
+//A currency that is not known to have another exponent has 2
+func CurrencyExponent(crcy string) (int, error) {
+	if !validCrcy(crcy) {
+		return 0, ErrBadCurrency
+	}
+	if exp, ok := minorUnits[crcy]; ok {
+		return exp, nil
+	}
+	return 2, nil
+}
+
+//Money is an amount in the minor units of a currency, e.g. 100 GBP is £1.00 and 100 JPY is ¥100. Use the constructors
+type Money struct {
+	amount   int64
+	crcy     string
+	exponent int
+}
+
+//NewMoney constructor. amount is in the minor units of crcy
+func NewMoney(amount int64, crcy string) (Money, error) {
+	exp, err := CurrencyExponent(crcy)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{amount: amount, crcy: crcy, exponent: exp}, nil
+}
+
+//MustNewMoney constructor. Will Panic if crcy is not a valid currency code
+func MustNewMoney(amount int64, crcy string) Money {
+	m, err := NewMoney(amount, crcy)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+//ParseMoney parses a decimal string, e.g. "-1234.5", as an amount of crcy.
+//ErrBadMoney is returned if it has more decimal places than the currency's minor unit
+func ParseMoney(s, crcy string) (Money, error) {
+	m, err := NewMoney(0, crcy)
+	if err != nil {
+		return Money{}, err
+	}
+	s = strings.TrimSpace(s)
+	neg := strings.HasPrefix(s, "-")
+	if neg || strings.HasPrefix(s, "+") {
+		s = s[1:]
+	}
+	units, fraction := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		units, fraction = s[:i], s[i+1:]
+	}
+	if units == "" && fraction == "" || len(fraction) > m.exponent {
+		return Money{}, fmt.Errorf("%w: %q", ErrBadMoney, s)
+	}
+	digits := units + fraction + strings.Repeat("0", m.exponent-len(fraction))
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return Money{}, fmt.Errorf("%w: %q", ErrBadMoney, s)
+		}
+		d := int64(c - '0')
+		if m.amount > (math.MaxInt64-d)/10 {
+			return Money{}, ErrMoneyOverflow
+		}
+		m.amount = m.amount*10 + d
+	}
+	if neg {
+		m.amount = -m.amount
+	}
+	return m, nil
+}
+
+//MustParseMoney parses a decimal string as an amount of crcy. Will Panic if it cannot be parsed
+func MustParseMoney(s, crcy string) Money {
+	m, err := ParseMoney(s, crcy)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+//Amount returns the amount in minor units
+func (m Money) Amount() int64 {
+	return m.amount
+}
+
+//Crcy returns the currency code
+func (m Money) Crcy() string {
+	return m.crcy
+}
+
+//Exponent returns the minor unit exponent of the currency
+func (m Money) Exponent() int {
+	return m.exponent
+}
+
+//IsZero returns true if the amount is zero
+func (m Money) IsZero() bool {
+	return m.amount == 0
+}
+
+//Decimal returns the amount as a decimal string with the currency's number of decimal places, e.g. "-12.50"
+func (m Money) Decimal() string {
+	sign, amount := "", uint64(m.amount)
+	if m.amount < 0 {
+		sign, amount = "-", uint64(-m.amount)
+	}
+	digits := fmt.Sprintf("%0*d", m.exponent+1, amount)
+	if m.exponent == 0 {
+		return sign + digits
+	}
+	point := len(digits) - m.exponent
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+//String implements Stringify interface, e.g. "12.50 GBP"
+func (m Money) String() string {
+	return m.Decimal() + " " + m.crcy
+}
+
+//Add returns the sum of two amounts in the same currency
+func (m Money) Add(o Money) (Money, error) {
+	if m.crcy != o.crcy {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrMoneyCurrency, m.crcy, o.crcy)
+	}
+	if (o.amount > 0 && m.amount > math.MaxInt64-o.amount) || (o.amount < 0 && m.amount < math.MinInt64-o.amount) {
+		return Money{}, ErrMoneyOverflow
+	}
+	m.amount += o.amount
+	return m, nil
+}
+
+//Sub returns the difference of two amounts in the same currency
+func (m Money) Sub(o Money) (Money, error) {
+	if m.crcy != o.crcy {
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrMoneyCurrency, m.crcy, o.crcy)
+	}
+	if (o.amount < 0 && m.amount > math.MaxInt64+o.amount) || (o.amount > 0 && m.amount < math.MinInt64+o.amount) {
+		return Money{}, ErrMoneyOverflow
+	}
+	m.amount -= o.amount
+	return m, nil
+}
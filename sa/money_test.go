@@ -0,0 +1,122 @@
+//go:build unit || integration
+// +build unit integration
+
+package sa_test
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"github.com/chippyash/go-simple-accounts/sa"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParseMoney(t *testing.T) {
+	for _, tc := range []struct {
+		s, crcy string
+		amount  int64
+		decimal string
+	}{
+		{"12.34", "GBP", 1234, "12.34"},
+		{"-12.5", "GBP", -1250, "-12.50"},
+		{" +0.05 ", "GBP", 5, "0.05"},
+		{"100", "GBP", 10000, "100.00"},
+		{".5", "EUR", 50, "0.50"},
+		{"100", "JPY", 100, "100"},
+		{"1.234", "KWD", 1234, "1.234"},
+		{"-0.001", "BHD", -1, "-0.001"},
+	} {
+		m, err := sa.ParseMoney(tc.s, tc.crcy)
+		assert.NoError(t, err, tc.s)
+		assert.Equal(t, tc.amount, m.Amount(), tc.s)
+		assert.Equal(t, tc.crcy, m.Crcy(), tc.s)
+		assert.Equal(t, tc.decimal, m.Decimal(), tc.s)
+	}
+	assert.Equal(t, "12.50 GBP", sa.MustParseMoney("12.5", "GBP").String())
+
+	for _, s := range []string{"", "-", ".", "1.234", "1,000.00", "abc", "1.2.3", "--1"} {
+		_, err := sa.ParseMoney(s, "GBP")
+		assert.ErrorIs(t, err, sa.ErrBadMoney, s)
+	}
+	_, err := sa.ParseMoney("1.5", "JPY")
+	assert.ErrorIs(t, err, sa.ErrBadMoney)
+	_, err = sa.ParseMoney("92233720368547758.08", "GBP")
+	assert.ErrorIs(t, err, sa.ErrMoneyOverflow)
+	_, err = sa.ParseMoney("1.00", "gbp")
+	assert.ErrorIs(t, err, sa.ErrBadCurrency)
+}
+
+func TestCurrencyExponent(t *testing.T) {
+	for crcy, expected := range map[string]int{"GBP": 2, "USD": 2, "JPY": 0, "KWD": 3, "CLF": 4} {
+		exp, err := sa.CurrencyExponent(crcy)
+		assert.NoError(t, err, crcy)
+		assert.Equal(t, expected, exp, crcy)
+		assert.Equal(t, expected, sa.MustNewMoney(1, crcy).Exponent(), crcy)
+	}
+	_, err := sa.NewMoney(100, "pounds")
+	assert.ErrorIs(t, err, sa.ErrBadCurrency)
+}
+
+func TestMoney_AddAndSub(t *testing.T) {
+	sum, err := sa.MustNewMoney(1250, "GBP").Add(sa.MustNewMoney(-250, "GBP"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000), sum.Amount())
+	diff, err := sum.Sub(sa.MustNewMoney(1000, "GBP"))
+	assert.NoError(t, err)
+	assert.True(t, diff.IsZero())
+
+	_, err = sa.MustNewMoney(100, "GBP").Add(sa.MustNewMoney(100, "EUR"))
+	assert.ErrorIs(t, err, sa.ErrMoneyCurrency)
+	_, err = sa.MustNewMoney(100, "GBP").Sub(sa.MustNewMoney(100, "EUR"))
+	assert.ErrorIs(t, err, sa.ErrMoneyCurrency)
+
+	_, err = sa.MustNewMoney(math.MaxInt64, "GBP").Add(sa.MustNewMoney(1, "GBP"))
+	assert.ErrorIs(t, err, sa.ErrMoneyOverflow)
+	_, err = sa.MustNewMoney(math.MinInt64, "GBP").Add(sa.MustNewMoney(-1, "GBP"))
+	assert.ErrorIs(t, err, sa.ErrMoneyOverflow)
+	_, err = sa.MustNewMoney(math.MinInt64, "GBP").Sub(sa.MustNewMoney(1, "GBP"))
+	assert.ErrorIs(t, err, sa.ErrMoneyOverflow)
+	_, err = sa.MustNewMoney(0, "GBP").Sub(sa.MustNewMoney(math.MinInt64, "GBP"))
+	assert.ErrorIs(t, err, sa.ErrMoneyOverflow)
+	assert.Equal(t, "-92233720368547758.08", sa.MustNewMoney(math.MinInt64, "GBP").Decimal())
+}
+
+func TestAccountant_WriteMoneyTransaction(t *testing.T) {
+	dt, _ := time.Parse(time.RFC3339, "2021-01-31T12:00:00Z")
+	for name, accountant := range storeTestAccountants(t) {
+		jrnId, err := accountant.WriteTransactionWithDate(
+			sa.NewMoneyTransactionBuilder(0, "1210", "4100", sa.MustParseMoney("12.50", "GBP")).Build(),
+			dt,
+		)
+		assert.NoError(t, err, name)
+		jrn, _ := accountant.FetchTransaction(jrnId)
+		entry, _ := jrn.GetEntry("1210")
+		assert.Equal(t, int64(1250), entry.Amount(), name)
+		assert.Equal(t, "", entry.Crcy(), name)
+
+		//money in another currency is converted with the rate for whole units
+		assert.NoError(t, accountant.AddExchangeRates([]sa.ExchangeRate{{From: "JPY", To: "GBP", Date: dt, Rate: 0.0055}}), name)
+		yen := sa.MustParseMoney("10000", "JPY")
+		jrnId, err = accountant.WriteTransactionWithDate(
+			sa.NewSplitTransactionBuilder(0).
+				WithMoneyEntry("1220", yen, *sa.NewAcType().Dr()).
+				WithMoneyEntry("4100", yen, *sa.NewAcType().Cr()).
+				Build(),
+			dt,
+		)
+		assert.NoError(t, err, name)
+		jrn, _ = accountant.FetchTransaction(jrnId)
+		entry, _ = jrn.GetEntry("1220")
+		assert.Equal(t, int64(5500), entry.Amount(), name)
+		assert.Equal(t, "JPY", entry.Crcy(), name)
+		assert.Equal(t, int64(10000), entry.CrcyAmount(), name)
+	}
+}
@@ -0,0 +1,615 @@
+package sa
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"strings"
+	"time"
+)
+
+//MysqlStore is a Store using a MySql/MariaDb database and the Simple Accounts stored routines
+type MysqlStore struct {
+	db *sql.DB
+	tx *sql.Tx
+}
+
+//mysqlTxStore is a MysqlStore bound to a database transaction
+type mysqlTxStore struct {
+	*MysqlStore
+}
+
+//NewMysqlStore constructor
+func NewMysqlStore(db *sql.DB) *MysqlStore {
+	return &MysqlStore{
+		db: db,
+	}
+}
+
+//Begin starts a database transaction and returns a Store bound to it
+func (s *MysqlStore) Begin(ctx context.Context) (TxStore, error) {
+	if s.tx != nil {
+		return nil, ErrNestedTransaction
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &mysqlTxStore{MysqlStore: &MysqlStore{db: s.db, tx: tx}}, nil
+}
+
+//Commit commits the transaction
+func (s *mysqlTxStore) Commit() error {
+	return s.tx.Commit()
+}
+
+//Rollback rolls back the transaction
+func (s *mysqlTxStore) Rollback() error {
+	return s.tx.Rollback()
+}
+
+//conn returns the transaction that the store is bound to, else the database
+func (s *MysqlStore) conn() dbtx {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
+
+//SchemaVersion returns the version of the database schema, and whether the last migration to it failed
+func (s *MysqlStore) SchemaVersion(ctx context.Context) (uint, bool, error) {
+	return readSchemaVersion(ctx, s.conn())
+}
+
+//withTx runs f within the transaction that the store is bound to, else within a new transaction
+func (s *MysqlStore) withTx(ctx context.Context, f func(tx dbtx) error) error {
+	if s.tx != nil {
+		return f(s.tx)
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err = f(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+//CreateChart stores a new chart and returns its id
+func (s *MysqlStore) CreateChart(ctx context.Context, name string) (uint64, error) {
+	res, err := s.conn().QueryContext(ctx, "select sa_fu_add_chart(?) as lastId", name)
+	if err != nil {
+		return 0, err
+	}
+	if res.Err() != nil {
+		return 0, res.Err()
+	}
+	var lastId int64 = 0
+	defer res.Close()
+	if !res.Next() {
+		return 0, nil
+	}
+	if err = res.Scan(&lastId); err != nil {
+		return 0, err
+	}
+	_, err = s.conn().ExecContext(ctx, "update sa_coa set created = ? where id = ?", time.Now().UTC(), lastId)
+	return uint64(lastId), err
+}
+
+//FetchChartName returns the name of a chart
+func (s *MysqlStore) FetchChartName(ctx context.Context, chartId uint64) (string, error) {
+	res, err := s.conn().QueryContext(ctx, "select name from sa_coa where id = ?", chartId)
+	if err != nil {
+		return "", err
+	}
+	if res.Err() != nil {
+		return "", res.Err()
+	}
+	var chartName string
+	defer res.Close()
+	if !res.Next() {
+		return "", ErrNoChartName
+	}
+	err = res.Scan(&chartName)
+	if err != nil {
+		return "", err
+	}
+	return chartName, nil
+}
+
+//FetchChartInfo returns the name and metadata of a chart
+func (s *MysqlStore) FetchChartInfo(ctx context.Context, chartId uint64) (*ChartInfo, error) {
+	return scanChartInfo(s.conn().QueryRowContext(ctx, "select "+chartInfoColumns+" from sa_coa where id = ?", chartId))
+}
+
+//SetChartInfo sets the currency, description and fiscal year start of a chart.
+//MySQL does not count unchanged rows as affected, so the chart is checked first
+func (s *MysqlStore) SetChartInfo(ctx context.Context, chartId uint64, info ChartInfo) error {
+	var cnt int
+	err := s.conn().QueryRowContext(ctx, "select count(id) from sa_coa where id = ?", chartId).Scan(&cnt)
+	if err != nil {
+		return err
+	}
+	if cnt == 0 {
+		return ErrChartNotFound
+	}
+	_, err = s.conn().ExecContext(ctx,
+		"update sa_coa set crcy = ?, description = ?, fiscalYearStart = ? where id = ?",
+		nullCrcy(info.Crcy), info.Description, nullTime(info.FiscalYearStart), chartId,
+	)
+	return err
+}
+
+//ListCharts returns the name and metadata of every chart, ordered by id
+func (s *MysqlStore) ListCharts(ctx context.Context) ([]ChartInfo, error) {
+	return listCharts(ctx, s.conn())
+}
+
+//RenameChart renames a chart
+func (s *MysqlStore) RenameChart(ctx context.Context, chartId uint64, name string) error {
+	return s.withTx(ctx, func(tx dbtx) error {
+		var cnt int
+		err := tx.QueryRowContext(ctx, "select count(id) from sa_coa where id = ?", chartId).Scan(&cnt)
+		if err != nil {
+			return err
+		}
+		if cnt == 0 {
+			return ErrChartNotFound
+		}
+		err = tx.QueryRowContext(ctx, "select count(id) from sa_coa where name = ? and id <> ?", name, chartId).Scan(&cnt)
+		if err != nil {
+			return err
+		}
+		if cnt > 0 {
+			return ErrChartExists
+		}
+		_, err = tx.ExecContext(ctx, "update sa_coa set name = ? where id = ?", name, chartId)
+		return err
+	})
+}
+
+//DeleteChart deletes a chart. Its ledgers, journals and periods are deleted by the foreign key cascades
+func (s *MysqlStore) DeleteChart(ctx context.Context, chartId uint64) error {
+	res, err := s.conn().ExecContext(ctx, "delete from sa_coa where id = ?", chartId)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n != 1 {
+		return ErrChartNotFound
+	}
+	return nil
+}
+
+//AddLedger adds a ledger to a chart
+func (s *MysqlStore) AddLedger(ctx context.Context, chartId uint64, nominal Nominal, tpe *AccountType, name string, prnt Nominal) error {
+	acType, ok := GetValuedAccountTypes()[*tpe]
+	if !ok {
+		return ErrBadAccountType
+	}
+	_, err := s.conn().ExecContext(ctx, "call sa_sp_add_ledger(?, ?, ?, ?, ?)",
+		chartId,
+		nominal.String(),
+		acType,
+		name,
+		prnt.String(),
+	)
+	return err
+}
+
+//DelLedger deletes a ledger and its child ledgers
+func (s *MysqlStore) DelLedger(ctx context.Context, chartId uint64, nominal Nominal) error {
+	_, err := s.conn().ExecContext(ctx, "call sa_sp_del_ledger(?, ?)",
+		chartId,
+		nominal.String(),
+	)
+	return err
+}
+
+//FetchLedgers returns the ledgers of a chart
+func (s *MysqlStore) FetchLedgers(ctx context.Context, chartId uint64) (Ledgers, error) {
+	res, err := s.conn().QueryContext(ctx, "call sa_sp_get_tree(?)", chartId)
+	if err != nil {
+		return nil, err
+	}
+	if res.Err() != nil {
+		return nil, res.Err()
+	}
+
+	ledgers := make(Ledgers, 0)
+	defer res.Close()
+	for res.Next() {
+		l := Ledger{}
+		err = res.Scan(&l.PrntId, &l.Id, &l.Nominal, &l.Name, &l.Tpe, &l.AcDr, &l.AcCr, &l.ChartId)
+		if err != nil {
+			return nil, err
+		}
+		ledgers = append(ledgers, l)
+	}
+	return ledgers, res.Err()
+}
+
+//WriteJournal stores a journal using the sa_fu_add_txn function.
+//Ledger values are updated by the sp_tr_jrn_entry_updt trigger. A reversal journal voids the journal that it reverses
+func (s *MysqlStore) WriteJournal(ctx context.Context, chartId uint64, txn *SplitTransaction, dt time.Time) (uint64, error) {
+	return s.writeJournal(ctx, chartId, txn, dt, false)
+}
+
+//WriteKeyedJournal stores a journal with its src and ref as a key that is unique within the chart
+func (s *MysqlStore) WriteKeyedJournal(ctx context.Context, chartId uint64, txn *SplitTransaction, dt time.Time) (uint64, error) {
+	return s.writeJournal(ctx, chartId, txn, dt, true)
+}
+
+func (s *MysqlStore) writeJournal(ctx context.Context, chartId uint64, txn *SplitTransaction, dt time.Time, keyed bool) (uint64, error) {
+	entryLen := len(txn.Entries())
+	var nominals = make([]string, entryLen)
+	var amounts = make([]string, entryLen)
+	var tpes = make([]string, entryLen)
+	acTypes := GetValuedAccountTypes()
+	for i, tx := range txn.Entries() {
+		nominals[i] = tx.Id().String()
+		amounts[i] = fmt.Sprintf("%d", tx.Amount())
+		tpes[i] = acTypes[*tx.Type()]
+	}
+	var jrnId uint64
+	write := func(tx dbtx) error {
+		var err error
+		if entryLen == 0 {
+			//sa_fu_add_txn always adds at least one entry, so a journal without entries is inserted directly
+			res, err := tx.ExecContext(ctx,
+				"insert into sa_journal (chartId, note, date, src, ref) values (?, ?, ?, ?, ?)",
+				chartId, txn.Note(), dt, txn.Src(), txn.Ref(),
+			)
+			if err != nil {
+				return err
+			}
+			id, err := res.LastInsertId()
+			if err != nil {
+				return err
+			}
+			jrnId = uint64(id)
+		} else {
+			err = tx.QueryRowContext(ctx,
+				"select sa_fu_add_txn(?, ?, ?, ?, ?, ?, ?, ?) as txnId",
+				chartId,
+				txn.Note(),
+				dt,
+				txn.Src(),
+				txn.Ref(),
+				strings.Join(nominals, ","),
+				strings.Join(amounts, ","),
+				strings.Join(tpes, ","),
+			).Scan(&jrnId)
+			if err == sql.ErrNoRows {
+				return ErrNoJrnId
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if err = s.writeEntryCurrencies(ctx, tx, jrnId, txn.Entries()); err != nil {
+			return err
+		}
+		if keyed {
+			_, err = tx.ExecContext(ctx, "update sa_journal set srcKey = 1 where id = ?", jrnId)
+			if isDuplicateKey(err) {
+				return ErrDuplicateJournal
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if txn.ReversalOf() == 0 {
+			return nil
+		}
+
+		_, err = tx.ExecContext(ctx, "update sa_journal set reversalOf = ? where id = ?", txn.ReversalOf(), jrnId)
+		if err != nil {
+			return err
+		}
+		res, err := tx.ExecContext(ctx,
+			"update sa_journal set reversedBy = ? where id = ? and chartId = ? and reversedBy is null",
+			jrnId, txn.ReversalOf(), chartId,
+		)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil || n != 1 {
+			return ErrJournalVoid
+		}
+		return nil
+	}
+	//the journal is written before its key is set, so a duplicate key within a bound transaction
+	//must roll back the journal and its ledger updates
+	if keyed && s.tx != nil {
+		write = inSavepoint(ctx, write)
+	}
+	if err := s.withTx(ctx, write); err != nil {
+		return 0, err
+	}
+
+	return jrnId, nil
+}
+
+//writeEntryCurrencies sets the transaction currency of the entries written by sa_fu_add_txn,
+//which only stores their chart currency values. It walks the entry arrays from the last entry to the first,
+//so the entry rows are read in descending id order to pair them with the entries
+func (s *MysqlStore) writeEntryCurrencies(ctx context.Context, tx dbtx, jrnId uint64, entries Entries) error {
+	foreign := entries.Filter(func(entry *Entry) bool {
+		return entry.Crcy() != ""
+	})
+	if len(foreign) == 0 {
+		return nil
+	}
+	res, err := tx.QueryContext(ctx, "select id from sa_journal_entry where jrnId = ? order by id desc", jrnId)
+	if err != nil {
+		return err
+	}
+	ids := make([]uint64, 0, len(entries))
+	for res.Next() {
+		var id uint64
+		if err = res.Scan(&id); err != nil {
+			_ = res.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	if err = res.Close(); err != nil {
+		return err
+	}
+	if len(ids) != len(entries) {
+		return ErrEntryNotFound
+	}
+	for i, entry := range entries {
+		if entry.Crcy() == "" {
+			continue
+		}
+		_, err = tx.ExecContext(ctx,
+			"update sa_journal_entry set crcy = ?, crcyAmount = ?, rate = ? where id = ?",
+			entry.Crcy(), entry.CrcyAmount(), entry.Rate(), ids[i],
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//FetchJournal returns a journal and all of its entries
+func (s *MysqlStore) FetchJournal(ctx context.Context, chartId, jrnId uint64) (*SplitTransaction, error) {
+	//the journal
+	var note string
+	var dt time.Time
+	var src string
+	var ref, reversalOf, reversedBy uint64
+	err := s.conn().QueryRowContext(ctx, "select note, date, src, ref, coalesce(reversalOf, 0), coalesce(reversedBy, 0) from sa_journal where id = ? and chartId = ?", jrnId, chartId).
+		Scan(&note, &dt, &src, &ref, &reversalOf, &reversedBy)
+	if err == sql.ErrNoRows {
+		return nil, ErrJournalNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	journal := NewSplitTransactionBuilder(jrnId).
+		WithDate(dt).
+		WithNote(note).
+		WithReference(ref).
+		WithSource(src).
+		WithReversalOf(reversalOf).
+		WithReversedBy(reversedBy)
+
+	//journal entries
+	res2, err := s.conn().QueryContext(ctx, "select "+entryColumns("e")+" from sa_journal_entry as e where e.jrnId = ? order by e.id", jrnId)
+	if err != nil {
+		return nil, err
+	}
+	if res2.Err() != nil {
+		return nil, res2.Err()
+	}
+	defer res2.Close()
+	for res2.Next() {
+		var e storedEntry
+		err = res2.Scan(e.dest()...)
+		if err != nil {
+			return nil, err
+		}
+		journal = journal.WithEntry(*e.entry())
+	}
+
+	return journal.Build(), res2.Err()
+}
+
+//FetchAccountJournals returns the journals for a ledger
+func (s *MysqlStore) FetchAccountJournals(ctx context.Context, chartId uint64, nominal Nominal) ([]*SplitTransaction, error) {
+	response := make([]*SplitTransaction, 0)
+	complexSelect := `
+select j.id, j.note, j.date, j.src, j.ref, coalesce(j.reversalOf, 0), coalesce(j.reversedBy, 0), ` + entryColumns("e") + `
+from sa_journal as j
+join sa_journal_entry as e
+on j.id = e.jrnid
+where e.nominal = ? and j.chartId = ?
+order by j.id
+`
+	res, err := s.conn().QueryContext(ctx, complexSelect, nominal.String(), chartId)
+	if err != nil {
+		return nil, err
+	}
+	if res.Err() != nil {
+		return nil, res.Err()
+	}
+	defer res.Close()
+	var id, ref, reversalOf, reversedBy uint64
+	var note, src string
+	var date time.Time
+	for res.Next() {
+		var e storedEntry
+		err = res.Scan(append([]interface{}{&id, &note, &date, &src, &ref, &reversalOf, &reversedBy}, e.dest()...)...)
+		if err != nil {
+			return nil, err
+		}
+		journal := NewSplitTransactionBuilder(id).
+			WithNote(note).
+			WithDate(date).
+			WithSource(src).
+			WithReference(ref).
+			WithReversalOf(reversalOf).
+			WithReversedBy(reversedBy).
+			WithEntry(*e.entry()).
+			Build()
+		response = append(response, journal)
+	}
+
+	return response, res.Err()
+}
+
+//QueryJournals returns the journals selected by the filter, with all their entries
+func (s *MysqlStore) QueryJournals(ctx context.Context, chartId uint64, filter JournalFilter) ([]*SplitTransaction, error) {
+	return queryJournals(ctx, s.conn(), func(query string) string { return query }, chartId, filter)
+}
+
+//IterateJournals returns an iterator over the journals selected by the filter, reading each journal and its
+//entries from a single ordered join
+func (s *MysqlStore) IterateJournals(ctx context.Context, chartId uint64, filter JournalFilter) (JournalIterator, error) {
+	return iterateJournals(ctx, s.conn(), func(query string) string { return query }, chartId, filter)
+}
+
+//AddPeriods adds accounting periods to a chart and returns them with their ids
+func (s *MysqlStore) AddPeriods(ctx context.Context, chartId uint64, periods []Period) ([]Period, error) {
+	added := make([]Period, len(periods))
+	err := s.withTx(ctx, func(tx dbtx) error {
+		for i, p := range periods {
+			res, err := tx.ExecContext(ctx,
+				"insert into sa_period (chartId, name, startDate, endDate, status) values (?, ?, ?, ?, ?)",
+				chartId, p.Name, p.Start.UTC(), p.End.UTC(), string(p.Status),
+			)
+			if err != nil {
+				return err
+			}
+			lastId, err := res.LastInsertId()
+			id := uint64(lastId)
+			if err != nil {
+				return err
+			}
+			p.Id = id
+			p.Start, p.End = p.Start.UTC(), p.End.UTC()
+			added[i] = p
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return added, nil
+}
+
+//FetchPeriods returns the accounting periods of a chart in date order
+func (s *MysqlStore) FetchPeriods(ctx context.Context, chartId uint64) ([]Period, error) {
+	res, err := s.conn().QueryContext(ctx, "select id, name, startDate, endDate, status from sa_period where chartId = ? order by startDate", chartId)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+	periods := make([]Period, 0)
+	for res.Next() {
+		p := Period{}
+		if err = res.Scan(&p.Id, &p.Name, &p.Start, &p.End, &p.Status); err != nil {
+			return nil, err
+		}
+		p.Start, p.End = p.Start.UTC(), p.End.UTC()
+		periods = append(periods, p)
+	}
+	return periods, res.Err()
+}
+
+//SetPeriodStatus sets the status of an accounting period
+func (s *MysqlStore) SetPeriodStatus(ctx context.Context, chartId, periodId uint64, status PeriodStatus) error {
+	res, err := s.conn().ExecContext(ctx, "update sa_period set status = ? where id = ? and chartId = ?", string(status), periodId, chartId)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n != 1 {
+		return ErrPeriodNotFound
+	}
+	return nil
+}
+
+//AddExchangeRates stores exchange rates, replacing any rate for the same currency pair and date
+func (s *MysqlStore) AddExchangeRates(ctx context.Context, rates []ExchangeRate) error {
+	return s.withTx(ctx, func(tx dbtx) error {
+		for _, rate := range rates {
+			_, err := tx.ExecContext(ctx,
+				"insert into sa_fx_rate (fromCrcy, toCrcy, rateDate, rate) values (?, ?, ?, ?) on duplicate key update rate = values(rate)",
+				rate.From, rate.To, rate.Date.UTC(), rate.Rate,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+//FetchExchangeRate returns the latest rate for the currency pair dated on or before dt
+func (s *MysqlStore) FetchExchangeRate(ctx context.Context, from, to string, dt time.Time) (*ExchangeRate, error) {
+	rate := &ExchangeRate{}
+	err := s.conn().QueryRowContext(ctx,
+		"select fromCrcy, toCrcy, rateDate, rate from sa_fx_rate where fromCrcy = ? and toCrcy = ? and rateDate <= ? order by rateDate desc limit 1",
+		from, to, dt.UTC(),
+	).Scan(&rate.From, &rate.To, &rate.Date, &rate.Rate)
+	if err == sql.ErrNoRows {
+		return nil, ErrNoExchangeRate
+	}
+	if err != nil {
+		return nil, err
+	}
+	rate.Date = rate.Date.UTC()
+	return rate, nil
+}
+
+//FetchLedgerTotals returns the sums of the journal entries for each ledger, for journals dated between from and to
+func (s *MysqlStore) FetchLedgerTotals(ctx context.Context, chartId uint64, from, to time.Time) ([]LedgerTotal, error) {
+	query := `
+select e.nominal, sum(e.acDr), sum(e.acCr)
+from sa_journal as j
+join sa_journal_entry as e
+on j.id = e.jrnId
+where j.chartId = ? and j.date <= ?
+`
+	args := []interface{}{chartId, to.UTC()}
+	if !from.IsZero() {
+		query += "and j.date >= ?\n"
+		args = append(args, from.UTC())
+	}
+	query += "group by e.nominal"
+	res, err := s.conn().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+	totals := make([]LedgerTotal, 0)
+	for res.Next() {
+		t := LedgerTotal{}
+		if err = res.Scan(&t.Nominal, &t.AcDr, &t.AcCr); err != nil {
+			return nil, err
+		}
+		totals = append(totals, t)
+	}
+	return totals, res.Err()
+}
+
+//isDuplicateKey returns true if err is a MySql unique index violation
+func isDuplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
@@ -9,40 +9,35 @@ package sa
  */
 
 import (
-	"database/sql"
-	"errors"
+	"context"
 	"github.com/chippyash/go-hierarchy-tree/tree"
 )
 
-//NodeSaver saves account ledger definitions to the database
+//NodeSaver saves account ledger definitions to the Store
 type NodeSaver struct {
 	tree.VisitorIFace
-	db *sql.DB
-	id uint64
+	ctx   context.Context
+	store Store
+	id    uint64
 }
 
 //NewNodeSaver constructor
-func NewNodeSaver(db *sql.DB) *NodeSaver {
+func NewNodeSaver(ctx context.Context, store Store) *NodeSaver {
 	return &NodeSaver{
-		db: db,
+		ctx:   ctx,
+		store: store,
 	}
 }
 
-//Visit store each tree node in the DB, Returns error or nil
+//Visit store each tree node in the Store, Returns error or nil
 func (v *NodeSaver) Visit(n tree.NodeIFace) interface{} {
 	currAc := n.GetValue().(*Account)
-	tpe, ok := GetValuedAccountTypes()[*currAc.Type()]
-	if !ok {
-		return errors.New("cannot retrieve account type string from value")
-	}
-	var prntNominal string
-	if n.IsRoot() {
-		prntNominal = ""
-	} else {
-		prntNominal = n.GetParent().GetValue().(*Account).Nominal().String()
+	var prntNominal Nominal
+	if !n.IsRoot() {
+		prntNominal = n.GetParent().GetValue().(*Account).Nominal()
 	}
 
-	_, err := v.db.Exec("call sa_sp_add_ledger(?, ?, ?, ?, ?)", currAc.chartId, currAc.Nominal().String(), tpe, currAc.Name(), prntNominal)
+	err := v.store.AddLedger(v.ctx, currAc.chartId, currAc.Nominal(), currAc.Type(), currAc.Name(), prntNominal)
 	if err != nil {
 		return err
 	}
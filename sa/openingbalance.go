@@ -0,0 +1,189 @@
+package sa
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//OpeningBalanceSource is the src of the opening balance journal. Its ref is always 1
+const OpeningBalanceSource = "OPNBAL"
+
+//OpeningBalance is the debit and credit balance of an account when it is moved onto the chart
+type OpeningBalance struct {
+	Nominal Nominal `json:"nominal"`
+	Dr      int64   `json:"dr"`
+	Cr      int64   `json:"cr"`
+}
+
+//OpeningBalances is a set of OpeningBalance
+type OpeningBalances []OpeningBalance
+
+//ReadOpeningBalancesCSV reads opening balances from CSV records of nominal,dr,cr.
+//The first record may be a header of column names
+func ReadOpeningBalancesCSV(r io.Reader) (OpeningBalances, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) > 0 && strings.EqualFold(strings.TrimSpace(records[0][0]), "nominal") {
+		records = records[1:]
+	}
+	balances := make(OpeningBalances, len(records))
+	for i, record := range records {
+		nominal, err := NewNominal(strings.TrimSpace(record[0]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		balances[i].Nominal = nominal
+		for j, amount := range []*int64{&balances[i].Dr, &balances[i].Cr} {
+			value := strings.TrimSpace(record[j+1])
+			if value == "" {
+				continue
+			}
+			if *amount, err = strconv.ParseInt(value, 10, 64); err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, ErrBadOpeningBalance)
+			}
+		}
+	}
+	return balances, balances.validate()
+}
+
+//ReadOpeningBalancesJSON reads opening balances from a JSON array of {"nominal": "1210", "dr": 100, "cr": 0} objects
+func ReadOpeningBalancesJSON(r io.Reader) (OpeningBalances, error) {
+	balances := make(OpeningBalances, 0)
+	if err := json.NewDecoder(r).Decode(&balances); err != nil {
+		return nil, err
+	}
+	return balances, balances.validate()
+}
+
+//Net returns the total debits less the total credits of the balances. It is zero if they balance
+func (b OpeningBalances) Net() int64 {
+	var net int64
+	for _, balance := range b {
+		net += balance.Dr - balance.Cr
+	}
+	return net
+}
+
+//validate checks that each balance has a valid nominal and amounts that are not negative
+func (b OpeningBalances) validate() error {
+	for _, balance := range b {
+		if _, err := NewNominal(string(balance.Nominal)); err != nil {
+			return ErrBadOpeningBalance
+		}
+		if balance.Dr < 0 || balance.Cr < 0 {
+			return ErrBadOpeningBalance
+		}
+	}
+	return nil
+}
+
+//PostOpeningBalances posts the balances as a single opening balance journal dated dt.
+//ErrUnbalancedOpening is returned if the balances do not net to zero,
+//and ErrOpeningBalancesPosted if the chart already has opening balances
+func (a *Accountant) PostOpeningBalances(balances OpeningBalances, dt time.Time) (uint64, error) {
+	return a.PostOpeningBalancesContext(context.Background(), balances, dt)
+}
+
+//PostOpeningBalancesContext posts the balances as a single opening balance journal dated dt
+func (a *Accountant) PostOpeningBalancesContext(ctx context.Context, balances OpeningBalances, dt time.Time) (uint64, error) {
+	return a.postOpeningBalances(ctx, balances, dt, nil)
+}
+
+//PostOpeningBalancesWithSuspense posts the balances as a single opening balance journal dated dt.
+//If the balances do not net to zero, the difference is posted to the suspense account,
+//e.g. 3100 Opening Balance
+func (a *Accountant) PostOpeningBalancesWithSuspense(balances OpeningBalances, dt time.Time, suspense Nominal) (uint64, error) {
+	return a.PostOpeningBalancesWithSuspenseContext(context.Background(), balances, dt, suspense)
+}
+
+//PostOpeningBalancesWithSuspenseContext posts the balances as a single opening balance journal dated dt,
+//with any difference posted to the suspense account
+func (a *Accountant) PostOpeningBalancesWithSuspenseContext(ctx context.Context, balances OpeningBalances, dt time.Time, suspense Nominal) (uint64, error) {
+	return a.postOpeningBalances(ctx, balances, dt, &suspense)
+}
+
+func (a *Accountant) postOpeningBalances(ctx context.Context, balances OpeningBalances, dt time.Time, suspense *Nominal) (uint64, error) {
+	if a.chartId == 0 {
+		return 0, ErrNoChartId
+	}
+	if err := a.checkSchema(ctx); err != nil {
+		return 0, err
+	}
+	if err := balances.validate(); err != nil {
+		return 0, err
+	}
+	net := balances.Net()
+	if net != 0 && suspense == nil {
+		return 0, ErrUnbalancedOpening
+	}
+
+	ledgers, err := a.store.FetchLedgers(ctx, a.chartId)
+	if err != nil {
+		return 0, err
+	}
+	nominals := make(map[Nominal]bool, len(ledgers))
+	for _, ledger := range ledgers {
+		nominals[ledger.Nominal] = true
+	}
+	if net != 0 {
+		balances = append(append(OpeningBalances(nil), balances...), OpeningBalance{Nominal: *suspense})
+		if net > 0 {
+			balances[len(balances)-1].Cr = net
+		} else {
+			balances[len(balances)-1].Dr = -net
+		}
+	}
+
+	drAc := *NewAcType().Dr()
+	crAc := *NewAcType().Cr()
+	txn := NewSplitTransactionBuilder(0).
+		WithDate(dt).
+		WithSource(OpeningBalanceSource).
+		WithReference(1).
+		WithNote("Opening balances")
+	entries := 0
+	for _, balance := range balances {
+		if !nominals[balance.Nominal] {
+			return 0, ErrBadNominal
+		}
+		if balance.Dr > 0 {
+			txn = txn.WithEntry(*NewEntry(balance.Nominal, balance.Dr, drAc))
+			entries++
+		}
+		if balance.Cr > 0 {
+			txn = txn.WithEntry(*NewEntry(balance.Nominal, balance.Cr, crAc))
+			entries++
+		}
+	}
+	if entries == 0 {
+		return 0, ErrNoOpeningBalances
+	}
+	if err = a.checkPeriod(ctx, dt); err != nil {
+		return 0, err
+	}
+
+	jrnId, err := a.store.WriteKeyedJournal(ctx, a.chartId, txn.Build(), dt)
+	if err == ErrDuplicateJournal {
+		return 0, ErrOpeningBalancesPosted
+	}
+	return jrnId, err
+}
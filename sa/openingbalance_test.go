@@ -0,0 +1,108 @@
+//go:build unit || integration
+// +build unit integration
+
+package sa_test
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"github.com/chippyash/go-simple-accounts/sa"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadOpeningBalancesCSV(t *testing.T) {
+	balances, err := sa.ReadOpeningBalancesCSV(strings.NewReader("nominal,dr,cr\n1210,1500,\n1220, 500, 0\n2100,,2000\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, sa.OpeningBalances{
+		{Nominal: "1210", Dr: 1500},
+		{Nominal: "1220", Dr: 500},
+		{Nominal: "2100", Cr: 2000},
+	}, balances)
+	assert.Equal(t, int64(0), balances.Net())
+
+	balances, err = sa.ReadOpeningBalancesCSV(strings.NewReader("1210,100,0\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), balances.Net())
+
+	_, err = sa.ReadOpeningBalancesCSV(strings.NewReader("1210,abc,0\n"))
+	assert.ErrorIs(t, err, sa.ErrBadOpeningBalance)
+	_, err = sa.ReadOpeningBalancesCSV(strings.NewReader("1210,-100,0\n"))
+	assert.ErrorIs(t, err, sa.ErrBadOpeningBalance)
+	_, err = sa.ReadOpeningBalancesCSV(strings.NewReader("bank,100,0\n"))
+	assert.ErrorIs(t, err, sa.ErrBadNominal)
+	_, err = sa.ReadOpeningBalancesCSV(strings.NewReader("1210,100\n"))
+	assert.Error(t, err)
+}
+
+func TestReadOpeningBalancesJSON(t *testing.T) {
+	balances, err := sa.ReadOpeningBalancesJSON(strings.NewReader(`[{"nominal": "1210", "dr": 1500}, {"nominal": "2100", "cr": 1500}]`))
+	assert.NoError(t, err)
+	assert.Equal(t, sa.OpeningBalances{
+		{Nominal: "1210", Dr: 1500},
+		{Nominal: "2100", Cr: 1500},
+	}, balances)
+
+	_, err = sa.ReadOpeningBalancesJSON(strings.NewReader(`[{"nominal": "1210", "dr": -1}]`))
+	assert.ErrorIs(t, err, sa.ErrBadOpeningBalance)
+	_, err = sa.ReadOpeningBalancesJSON(strings.NewReader(`{"nominal": "1210"}`))
+	assert.Error(t, err)
+}
+
+func TestAccountant_PostOpeningBalances(t *testing.T) {
+	dt, _ := time.Parse(time.RFC3339, "2020-04-01T00:00:00Z")
+	for name, accountant := range storeTestAccountants(t) {
+		_, err := accountant.PostOpeningBalances(sa.OpeningBalances{{Nominal: "1210", Dr: 100}}, dt)
+		assert.ErrorIs(t, err, sa.ErrUnbalancedOpening, name)
+		_, err = accountant.PostOpeningBalances(sa.OpeningBalances{{Nominal: "9999", Dr: 100}, {Nominal: "2100", Cr: 100}}, dt)
+		assert.ErrorIs(t, err, sa.ErrBadNominal, name)
+		_, err = accountant.PostOpeningBalances(sa.OpeningBalances{{Nominal: "1210"}}, dt)
+		assert.ErrorIs(t, err, sa.ErrNoOpeningBalances, name)
+
+		balances := sa.OpeningBalances{
+			{Nominal: "1210", Dr: 1500},
+			{Nominal: "1220", Dr: 500},
+			{Nominal: "2100", Cr: 2000},
+		}
+		jrnId, err := accountant.PostOpeningBalances(balances, dt)
+		assert.NoError(t, err, name)
+		jrn, err := accountant.FetchTransaction(jrnId)
+		assert.NoError(t, err, name)
+		assert.Equal(t, sa.OpeningBalanceSource, jrn.Src(), name)
+		assert.Equal(t, 3, len(jrn.Entries()), name)
+		for nominal, expected := range map[sa.Nominal]int64{"1210": 1500, "1220": 500, "2100": 2000} {
+			balance, err := accountant.BalanceAsAt(nominal, dt)
+			assert.NoError(t, err, name)
+			assert.Equal(t, expected, balance, "%s: %s", name, nominal)
+		}
+
+		_, err = accountant.PostOpeningBalances(balances, dt)
+		assert.ErrorIs(t, err, sa.ErrOpeningBalancesPosted, name)
+	}
+}
+
+func TestAccountant_PostOpeningBalancesWithSuspense(t *testing.T) {
+	dt, _ := time.Parse(time.RFC3339, "2020-04-01T00:00:00Z")
+	for name, accountant := range storeTestAccountants(t) {
+		balances := sa.OpeningBalances{
+			{Nominal: "1210", Dr: 1500},
+			{Nominal: "2100", Cr: 1000},
+		}
+		jrnId, err := accountant.PostOpeningBalancesWithSuspense(balances, dt, "3100")
+		assert.NoError(t, err, name)
+		assert.Equal(t, 2, len(balances), name)
+		jrn, _ := accountant.FetchTransaction(jrnId)
+		assert.Equal(t, 3, len(jrn.Entries()), name)
+		assert.True(t, jrn.CheckBalance(), name)
+		balance, _ := accountant.BalanceAsAt("3100", dt)
+		assert.Equal(t, int64(500), balance, name)
+	}
+}
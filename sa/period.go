@@ -0,0 +1,241 @@
+package sa
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+//PeriodStatus is the status of an accounting period
+type PeriodStatus string
+
+const (
+	//PeriodOpen periods accept journals
+	PeriodOpen PeriodStatus = "open"
+	//PeriodClosed periods do not accept journals, but can be reopened
+	PeriodClosed PeriodStatus = "closed"
+	//PeriodLocked periods do not accept journals, and cannot be reopened
+	PeriodLocked PeriodStatus = "locked"
+)
+
+//PeriodPattern is the way that a fiscal year is split into periods
+type PeriodPattern int
+
+const (
+	//PeriodsMonthly splits a fiscal year into twelve calendar months
+	PeriodsMonthly PeriodPattern = iota
+	//Periods445 splits a fiscal year into four quarters of 13 weeks, each of two four week periods
+	//and a five week period, so the year is 52 weeks
+	Periods445
+)
+
+//Period is an accounting period of a chart.
+//It starts at Start and ends at End, which is the Start of the next period
+type Period struct {
+	Id     uint64
+	Name   string
+	Start  time.Time
+	End    time.Time
+	Status PeriodStatus
+}
+
+//Contains returns true if dt is in the period
+func (p Period) Contains(dt time.Time) bool {
+	return !dt.Before(p.Start) && dt.Before(p.End)
+}
+
+//overlaps returns true if the period has any time in common with o
+func (p Period) overlaps(o Period) bool {
+	return p.Start.Before(o.End) && o.Start.Before(p.End)
+}
+
+//FiscalYearPeriods returns the open periods of a fiscal year that starts at start.
+//The periods are named after the year, e.g. FY2020 P01
+func FiscalYearPeriods(name string, start time.Time, pattern PeriodPattern) ([]Period, error) {
+	var ends []time.Time
+	switch pattern {
+	case PeriodsMonthly:
+		for i := 1; i <= 12; i++ {
+			ends = append(ends, start.AddDate(0, i, 0))
+		}
+	case Periods445:
+		weeks := 0
+		for i := 0; i < 12; i++ {
+			weeks += []int{4, 4, 5}[i%3]
+			ends = append(ends, start.AddDate(0, 0, 7*weeks))
+		}
+	default:
+		return nil, ErrBadPeriodPattern
+	}
+	periods := make([]Period, len(ends))
+	for i, end := range ends {
+		periods[i] = Period{
+			Name:   fmt.Sprintf("%s P%02d", name, i+1),
+			Start:  start,
+			End:    end,
+			Status: PeriodOpen,
+		}
+		start = end
+	}
+	return periods, nil
+}
+
+//DefineFiscalYear adds the periods of a fiscal year, that starts at start, to the chart and returns them
+func (a *Accountant) DefineFiscalYear(name string, start time.Time, pattern PeriodPattern) ([]Period, error) {
+	return a.DefineFiscalYearContext(context.Background(), name, start, pattern)
+}
+
+//DefineFiscalYearContext adds the periods of a fiscal year, that starts at start, to the chart and returns them
+func (a *Accountant) DefineFiscalYearContext(ctx context.Context, name string, start time.Time, pattern PeriodPattern) ([]Period, error) {
+	periods, err := FiscalYearPeriods(name, start, pattern)
+	if err != nil {
+		return nil, err
+	}
+	return a.AddPeriodsContext(ctx, periods)
+}
+
+//AddPeriods adds periods to the chart and returns them with their ids.
+//ErrPeriodOverlap is returned if a period overlaps another period
+func (a *Accountant) AddPeriods(periods []Period) ([]Period, error) {
+	return a.AddPeriodsContext(context.Background(), periods)
+}
+
+//AddPeriodsContext adds periods to the chart and returns them with their ids
+func (a *Accountant) AddPeriodsContext(ctx context.Context, periods []Period) ([]Period, error) {
+	if a.chartId == 0 {
+		return nil, ErrNoChartId
+	}
+	if err := a.checkSchema(ctx); err != nil {
+		return nil, err
+	}
+	existing, err := a.store.FetchPeriods(ctx, a.chartId)
+	if err != nil {
+		return nil, err
+	}
+	for i, p := range periods {
+		if !p.Start.Before(p.End) {
+			return nil, ErrBadPeriod
+		}
+		if p.Status == "" {
+			periods[i].Status = PeriodOpen
+		} else if !p.Status.valid() {
+			return nil, ErrBadPeriodStatus
+		}
+		for _, o := range append(existing, periods[:i]...) {
+			if p.overlaps(o) {
+				return nil, ErrPeriodOverlap
+			}
+		}
+	}
+	return a.store.AddPeriods(ctx, a.chartId, periods)
+}
+
+//Periods returns the periods of the chart in date order
+func (a *Accountant) Periods() ([]Period, error) {
+	return a.PeriodsContext(context.Background())
+}
+
+//PeriodsContext returns the periods of the chart in date order
+func (a *Accountant) PeriodsContext(ctx context.Context) ([]Period, error) {
+	if a.chartId == 0 {
+		return nil, ErrNoChartId
+	}
+	if err := a.checkSchema(ctx); err != nil {
+		return nil, err
+	}
+	return a.store.FetchPeriods(ctx, a.chartId)
+}
+
+//PeriodAt returns the period that contains dt, or ErrPeriodNotFound
+func (a *Accountant) PeriodAt(dt time.Time) (*Period, error) {
+	return a.PeriodAtContext(context.Background(), dt)
+}
+
+//PeriodAtContext returns the period that contains dt, or ErrPeriodNotFound
+func (a *Accountant) PeriodAtContext(ctx context.Context, dt time.Time) (*Period, error) {
+	periods, err := a.PeriodsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range periods {
+		if p.Contains(dt) {
+			return &p, nil
+		}
+	}
+	return nil, ErrPeriodNotFound
+}
+
+//ClosePeriod closes a period so that it does not accept journals. It can be reopened
+func (a *Accountant) ClosePeriod(periodId uint64) error {
+	return a.SetPeriodStatusContext(context.Background(), periodId, PeriodClosed)
+}
+
+//LockPeriod locks a period so that it does not accept journals. It cannot be reopened
+func (a *Accountant) LockPeriod(periodId uint64) error {
+	return a.SetPeriodStatusContext(context.Background(), periodId, PeriodLocked)
+}
+
+//ReopenPeriod reopens a closed period
+func (a *Accountant) ReopenPeriod(periodId uint64) error {
+	return a.SetPeriodStatusContext(context.Background(), periodId, PeriodOpen)
+}
+
+//SetPeriodStatus sets the status of a period. ErrPeriodLocked is returned if the period is locked
+func (a *Accountant) SetPeriodStatus(periodId uint64, status PeriodStatus) error {
+	return a.SetPeriodStatusContext(context.Background(), periodId, status)
+}
+
+//SetPeriodStatusContext sets the status of a period. ErrPeriodLocked is returned if the period is locked
+func (a *Accountant) SetPeriodStatusContext(ctx context.Context, periodId uint64, status PeriodStatus) error {
+	if !status.valid() {
+		return ErrBadPeriodStatus
+	}
+	periods, err := a.PeriodsContext(ctx)
+	if err != nil {
+		return err
+	}
+	for _, p := range periods {
+		if p.Id != periodId {
+			continue
+		}
+		if p.Status == PeriodLocked && status != PeriodLocked {
+			return ErrPeriodLocked
+		}
+		return a.store.SetPeriodStatus(ctx, a.chartId, periodId, status)
+	}
+	return ErrPeriodNotFound
+}
+
+//checkPeriod returns a *PeriodClosedError if dt is in a period that is not open
+func (a *Accountant) checkPeriod(ctx context.Context, dt time.Time) error {
+	periods, err := a.store.FetchPeriods(ctx, a.chartId)
+	if err != nil {
+		return err
+	}
+	for _, p := range periods {
+		if p.Contains(dt) && p.Status != PeriodOpen {
+			return &PeriodClosedError{Date: dt, Period: p}
+		}
+	}
+	return nil
+}
+
+func (s PeriodStatus) valid() bool {
+	return s == PeriodOpen || s == PeriodClosed || s == PeriodLocked
+}
+
+//sortPeriods sorts periods into date order
+func sortPeriods(periods []Period) {
+	sort.Slice(periods, func(i, j int) bool {
+		return periods[i].Start.Before(periods[j].Start)
+	})
+}
@@ -0,0 +1,131 @@
+//go:build unit || integration
+// +build unit integration
+
+package sa_test
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"errors"
+	"github.com/chippyash/go-simple-accounts/sa"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestFiscalYearPeriods(t *testing.T) {
+	start, _ := time.Parse(time.RFC3339, "2020-04-01T00:00:00Z")
+
+	periods, err := sa.FiscalYearPeriods("FY2020", start, sa.PeriodsMonthly)
+	assert.NoError(t, err)
+	assert.Equal(t, 12, len(periods))
+	assert.Equal(t, "FY2020 P01", periods[0].Name)
+	assert.Equal(t, start, periods[0].Start)
+	assert.Equal(t, start.AddDate(0, 1, 0), periods[0].End)
+	assert.Equal(t, start.AddDate(1, 0, 0), periods[11].End)
+	assert.Equal(t, sa.PeriodOpen, periods[11].Status)
+
+	periods, err = sa.FiscalYearPeriods("FY2020", start, sa.Periods445)
+	assert.NoError(t, err)
+	assert.Equal(t, 12, len(periods))
+	assert.Equal(t, start.AddDate(0, 0, 28), periods[0].End)
+	assert.Equal(t, start.AddDate(0, 0, 91), periods[2].End)
+	assert.Equal(t, start.AddDate(0, 0, 364), periods[11].End)
+	for i := 1; i < len(periods); i++ {
+		assert.Equal(t, periods[i-1].End, periods[i].Start)
+	}
+
+	_, err = sa.FiscalYearPeriods("FY2020", start, sa.PeriodPattern(99))
+	assert.ErrorIs(t, err, sa.ErrBadPeriodPattern)
+}
+
+func TestAccountant_DefineFiscalYear(t *testing.T) {
+	start, _ := time.Parse(time.RFC3339, "2020-04-01T00:00:00Z")
+	for name, accountant := range storeTestAccountants(t) {
+		periods, err := accountant.DefineFiscalYear("FY2020", start, sa.PeriodsMonthly)
+		assert.NoError(t, err, name)
+		assert.Equal(t, 12, len(periods), name)
+		assert.NotEqual(t, uint64(0), periods[0].Id, name)
+
+		fetched, err := accountant.Periods()
+		assert.NoError(t, err, name)
+		assert.Equal(t, periods, fetched, name)
+
+		period, err := accountant.PeriodAt(start.AddDate(0, 1, 15))
+		assert.NoError(t, err, name)
+		assert.Equal(t, "FY2020 P02", period.Name, name)
+		_, err = accountant.PeriodAt(start.AddDate(-1, 0, 0))
+		assert.ErrorIs(t, err, sa.ErrPeriodNotFound, name)
+
+		_, err = accountant.DefineFiscalYear("FY2020a", start.AddDate(0, 6, 0), sa.PeriodsMonthly)
+		assert.ErrorIs(t, err, sa.ErrPeriodOverlap, name)
+		_, err = accountant.AddPeriods([]sa.Period{{Name: "Bad", Start: start, End: start}})
+		assert.ErrorIs(t, err, sa.ErrBadPeriod, name)
+		_, err = accountant.AddPeriods([]sa.Period{{Name: "Bad", Start: start.AddDate(2, 0, 0), End: start.AddDate(3, 0, 0), Status: "shut"}})
+		assert.ErrorIs(t, err, sa.ErrBadPeriodStatus, name)
+
+		periods, err = accountant.DefineFiscalYear("FY2021", start.AddDate(1, 0, 0), sa.PeriodsMonthly)
+		assert.NoError(t, err, name)
+		fetched, _ = accountant.Periods()
+		assert.Equal(t, 24, len(fetched), name)
+	}
+}
+
+func TestAccountant_PeriodLocking(t *testing.T) {
+	start, _ := time.Parse(time.RFC3339, "2020-04-01T00:00:00Z")
+	inFirst := start.AddDate(0, 0, 10)
+	inSecond := start.AddDate(0, 1, 10)
+	for name, accountant := range storeTestAccountants(t) {
+		periods, err := accountant.DefineFiscalYear("FY2020", start, sa.PeriodsMonthly)
+		assert.NoError(t, err, name)
+		txn := sa.NewSimpleTransactionBuilder(0, "1210", "4100", 100).
+			WithSource("PAY").
+			WithReference(1).
+			Build()
+		jrnId, err := accountant.WriteTransactionWithDate(txn, inFirst)
+		assert.NoError(t, err, name)
+
+		assert.NoError(t, accountant.ClosePeriod(periods[0].Id), name)
+		_, err = accountant.WriteTransactionWithDate(txn, inFirst)
+		assert.ErrorIs(t, err, sa.ErrPeriodClosed, name)
+		var closedErr *sa.PeriodClosedError
+		assert.True(t, errors.As(err, &closedErr), name)
+		assert.Equal(t, periods[0].Id, closedErr.Period.Id, name)
+		assert.Equal(t, sa.PeriodClosed, closedErr.Period.Status, name)
+
+		//other periods, and dates outside any period, are still open
+		_, err = accountant.WriteTransactionWithDate(txn, inSecond)
+		assert.NoError(t, err, name)
+		_, err = accountant.WriteTransactionWithDate(txn, start.AddDate(2, 0, 0))
+		assert.NoError(t, err, name)
+
+		//reversals and idempotent writes are also checked
+		_, err = accountant.ReverseTransaction(jrnId, inFirst, "reverse")
+		assert.ErrorIs(t, err, sa.ErrPeriodClosed, name)
+		_, err = accountant.ReverseTransaction(jrnId, inSecond, "reverse")
+		assert.NoError(t, err, name)
+		_, err = accountant.WriteTransactionOnce(sa.NewSimpleTransactionBuilder(0, "1210", "4100", 100).
+			WithSource("PAY").
+			WithReference(99).
+			Build(), inFirst)
+		assert.ErrorIs(t, err, sa.ErrPeriodClosed, name)
+
+		assert.NoError(t, accountant.ReopenPeriod(periods[0].Id), name)
+		_, err = accountant.WriteTransactionWithDate(txn, inFirst)
+		assert.NoError(t, err, name)
+
+		assert.NoError(t, accountant.LockPeriod(periods[0].Id), name)
+		_, err = accountant.WriteTransactionWithDate(txn, inFirst)
+		assert.ErrorIs(t, err, sa.ErrPeriodClosed, name)
+		assert.ErrorIs(t, accountant.ReopenPeriod(periods[0].Id), sa.ErrPeriodLocked, name)
+		assert.ErrorIs(t, accountant.ClosePeriod(periods[0].Id), sa.ErrPeriodLocked, name)
+		assert.ErrorIs(t, accountant.ClosePeriod(999), sa.ErrPeriodNotFound, name)
+		assert.ErrorIs(t, accountant.SetPeriodStatus(periods[1].Id, "shut"), sa.ErrBadPeriodStatus, name)
+	}
+}
@@ -0,0 +1,39 @@
+package postgres
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/chippyash/go-simple-accounts/sa"
+	"github.com/lib/pq"
+)
+
+func init() {
+	sa.RegisterMigrations(&pq.Driver{}, "postgres")
+	sa.RegisterStore(&pq.Driver{}, func(db *sql.DB) sa.Store { return NewStore(db) })
+}
+
+//Dialect is the PostgreSQL dialect of a sa.SqlStore
+var Dialect = sa.SqlDialect{
+	NumberedParams: true,
+	IsDuplicateKey: isDuplicateKey,
+}
+
+//NewStore returns a Store using a PostgreSQL database built from the db/postgres schema.
+//The MySql stored routines and trigger are implemented in Go
+func NewStore(db *sql.DB) *sa.SqlStore {
+	return sa.NewSqlStore(db, Dialect)
+}
+
+//isDuplicateKey returns true if err is a PostgreSQL unique index violation
+func isDuplicateKey(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
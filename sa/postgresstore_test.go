@@ -0,0 +1,152 @@
+//go:build integration
+// +build integration
+
+package sa_test
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"database/sql"
+	"github.com/chippyash/go-simple-accounts/sa"
+	"github.com/chippyash/go-simple-accounts/sa/postgres"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+	"time"
+)
+
+var pgAccountant *sa.Accountant
+var pgDb *sql.DB
+
+//the PostgreSQL store, in a database that is rebuilt for each test
+func init() {
+	testAccountants["postgres"] = func(t *testing.T) *sa.Accountant {
+		setupPostgresStoreTest(t)
+		return pgAccountant
+	}
+}
+
+func TestPostgresStore_CreateChart(t *testing.T) {
+	setupPostgresStoreTest(t)
+	chart, err := pgAccountant.FetchChart()
+	assert.NoError(t, err)
+	assert.Equal(t, "Test", chart.Name())
+	assert.Equal(t, 5, chart.Tree().GetHeight())
+}
+
+func TestPostgresStore_WriteTransactionRollsUpParentLedgers(t *testing.T) {
+	setupPostgresStoreTest(t)
+	dt, _ := time.Parse(time.RFC3339, "2020-08-05T14:36:00+01:00")
+	txn := sa.NewSimpleTransactionBuilder(0, "1210", "4100", 100).Build()
+	_, err := pgAccountant.WriteTransactionWithDate(txn, dt)
+	assert.NoError(t, err)
+	txn = sa.NewSimpleTransactionBuilder(0, "6120", "1210", 10).Build()
+	_, err = pgAccountant.WriteTransactionWithDate(txn, dt)
+	assert.NoError(t, err)
+
+	values := map[string][]int64{
+		"0000": {110, 110},
+		"0001": {100, 10},
+		"1200": {100, 10},
+		"1210": {100, 10},
+		"0002": {10, 100},
+		"4100": {0, 100},
+		"6000": {10, 0},
+		"6120": {10, 0},
+	}
+	chart, _ := pgAccountant.FetchChart()
+	for nom, vals := range values {
+		ac := chart.GetAccount(sa.MustNewNominal(nom))
+		assert.Equal(t, vals[0], ac.Dr(), "DR a/c value: %d not equal %d for Nominal: %s", ac.Dr(), vals[0], nom)
+		assert.Equal(t, vals[1], ac.Cr(), "CR a/c value: %d not equal %d for Nominal: %s", ac.Cr(), vals[1], nom)
+	}
+}
+
+func TestPostgresStore_FetchTransaction(t *testing.T) {
+	setupPostgresStoreTest(t)
+	dt, _ := time.Parse(time.RFC3339, "2020-08-05T14:36:00+01:00")
+	txn := sa.NewSimpleTransactionBuilder(0, "1210", "4100", 100).Build()
+	jrnId, _ := pgAccountant.WriteTransactionWithDate(txn, dt)
+
+	journal, err := pgAccountant.FetchTransaction(jrnId)
+	assert.NoError(t, err)
+	assert.Equal(t, "2020-08-05T13:36:00Z", journal.Date().UTC().Format(time.RFC3339))
+	assert.Equal(t, 2, len(journal.Entries()))
+
+	journals, err := pgAccountant.FetchAccountJournals("1210")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(journals))
+}
+
+func TestPostgresStore_AddAndDelAccount(t *testing.T) {
+	setupPostgresStoreTest(t)
+	nom := sa.MustNewNominal("1111")
+	err := pgAccountant.AddAccount(nom, sa.NewAcType().Asset(), "foo", nil)
+	assert.ErrorIs(t, err, sa.ErrRootExists)
+	prnt := sa.MustNewNominal("9999")
+	err = pgAccountant.AddAccount(nom, sa.NewAcType().Asset(), "foo", &prnt)
+	assert.ErrorIs(t, err, sa.ErrBadParent)
+	prnt = sa.MustNewNominal("1000")
+	err = pgAccountant.AddAccount(nom, sa.NewAcType().Asset(), "foo", &prnt)
+	assert.NoError(t, err)
+
+	txn := sa.NewSimpleTransactionBuilder(0, "1111", "4100", 100).Build()
+	_, _ = pgAccountant.WriteTransaction(txn)
+	chart, _ := pgAccountant.FetchChart()
+	assert.Equal(t, int64(100), chart.GetAccount("1000").Dr())
+
+	err = pgAccountant.DelAccount(nom)
+	assert.ErrorIs(t, err, sa.ErrNonZeroBalance)
+	err = pgAccountant.DelAccount(sa.MustNewNominal("6100"))
+	assert.NoError(t, err)
+	chart, _ = pgAccountant.FetchChart()
+	assert.False(t, chart.HasAccount(sa.MustNewNominal("6123")))
+}
+
+func TestPostgresStore_ReverseTransaction(t *testing.T) {
+	setupPostgresStoreTest(t)
+	assertReverseTransaction(t, pgAccountant, "postgres")
+}
+
+func TestPostgresStore_FetchChartAsAt(t *testing.T) {
+	setupPostgresStoreTest(t)
+	assertChartAsAt(t, pgAccountant, "postgres")
+}
+
+func TestPostgresStore_AccountStatement(t *testing.T) {
+	setupPostgresStoreTest(t)
+	assertAccountStatement(t, pgAccountant, "postgres")
+}
+
+func TestPostgresStore_NoteWithWildcards(t *testing.T) {
+	setupPostgresStoreTest(t)
+	assertNoteWithWildcards(t, pgAccountant, "postgres")
+}
+
+func TestPostgresStore_KeyedJournalInTx(t *testing.T) {
+	setupPostgresStoreTest(t)
+	assertKeyedJournalInTx(t, postgres.NewStore(pgDb), "postgres")
+}
+
+//setupPostgresStoreTest rebuilds the schema in the database given by PGDSN, or by the
+//standard PGHOST, PGUSER, PGPASSWORD and PGDATABASE environment variables if PGDSN is empty
+func setupPostgresStoreTest(t *testing.T) {
+	dba, err := sql.Open("postgres", os.Getenv("PGDSN"))
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = dba.Close() })
+	_, err = dba.Exec("drop schema public cascade; create schema public")
+	assert.NoError(t, err)
+	assert.NoError(t, sa.Migrate(dba))
+	pgDb = dba
+	pgAccountant = sa.NewAccountant(postgres.NewStore(dba), 0, "GBP")
+	def, err := sa.NewChartDefinition("../tests/_data/personal.xml")
+	assert.NoError(t, err)
+	_, err = pgAccountant.CreateChart("Test", "GBP", def)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,222 @@
+package sa
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+//RevaluationSource is the src of foreign currency revaluation journals. Their ref is the revaluation date as yyyymmdd
+const RevaluationSource = "REVAL"
+
+//revaluedTypes are the account types whose foreign currency balances are revalued
+var revaluedTypes = map[string]bool{"BANK": true, "CUSTOMER": true, "SUPPLIER": true}
+
+//Revalue revalues the foreign currency balances of the BANK, CUSTOMER and SUPPLIER accounts as at asAt.
+//rates are the closing rates, by currency code, that convert a transaction currency amount to the chart currency.
+//They are for whole units, as they are quoted, e.g. 0.0055 for JPY to GBP, in the same way as Entry.Rate and the rates
+//of the Accountant's exchange rate provider, which is used for a currency that is not in rates.
+//The previous revaluation is reversed, and then a journal, dated asAt, is posted that adjusts the chart currency
+//value of each foreign currency balance to its value at the closing rate, with the unrealised gain or loss
+//posted to the gainLoss account.
+//The id of the revaluation journal is returned, or zero if there was nothing to revalue.
+//ErrBadExchangeRate is returned if a rate is not positive or its currency is not a three letter code,
+//and ErrBadCurrency if the chart currency is not known
+func (a *Accountant) Revalue(asAt time.Time, rates map[string]float64, gainLoss Nominal) (uint64, error) {
+	return a.RevalueContext(context.Background(), asAt, rates, gainLoss)
+}
+
+//RevalueContext revalues the foreign currency balances of the BANK, CUSTOMER and SUPPLIER accounts as at asAt
+func (a *Accountant) RevalueContext(ctx context.Context, asAt time.Time, rates map[string]float64, gainLoss Nominal) (uint64, error) {
+	if a.chartId == 0 {
+		return 0, ErrNoChartId
+	}
+	for crcy, rate := range rates {
+		if !validCrcy(crcy) || !(rate > 0) {
+			return 0, ErrBadExchangeRate
+		}
+	}
+	if err := a.checkSchema(ctx); err != nil {
+		return 0, err
+	}
+	if _, ok := a.store.(TxStore); ok {
+		return a.revalue(ctx, asAt, rates, gainLoss)
+	}
+	var jrnId uint64
+	err := a.InTxContext(ctx, func(tx *AccountantTx) error {
+		var err error
+		jrnId, err = tx.revalue(ctx, asAt, rates, gainLoss)
+		return err
+	})
+	return jrnId, err
+}
+
+//foreignBalance is the balance of an account in a transaction currency
+type foreignBalance struct {
+	nominal Nominal
+	crcy    string
+	//crcyAmount and amount are debit less credit, in the transaction currency and the chart currency
+	crcyAmount int64
+	amount     int64
+}
+
+func (a *Accountant) revalue(ctx context.Context, asAt time.Time, rates map[string]float64, gainLoss Nominal) (uint64, error) {
+	ledgers, err := a.store.FetchLedgers(ctx, a.chartId)
+	if err != nil {
+		return 0, err
+	}
+	nominals := make([]Nominal, 0)
+	revalued := make(map[Nominal]bool)
+	hasGainLoss := false
+	for _, ledger := range ledgers {
+		if revaluedTypes[ledger.Tpe] {
+			nominals = append(nominals, ledger.Nominal)
+			revalued[ledger.Nominal] = true
+		}
+		hasGainLoss = hasGainLoss || ledger.Nominal == gainLoss
+	}
+	if !hasGainLoss {
+		return 0, ErrBadNominal
+	}
+
+	//reverse the previous revaluation
+	previous, err := a.previousRevaluation(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if previous != nil {
+		if previous.Date().After(asAt) {
+			return 0, ErrRevaluationOrder
+		}
+		note := fmt.Sprintf("Reverse revaluation at %s", previous.Date().Format("2006-01-02"))
+		if _, err = a.ReverseTransactionContext(ctx, previous.Id(), asAt, note); err != nil {
+			return 0, err
+		}
+	}
+	if len(nominals) == 0 {
+		return 0, nil
+	}
+
+	//the foreign currency balances. Revaluation journals are in the chart currency, so they are not included
+	balances := make(map[Nominal]map[string]*foreignBalance)
+	it, err := a.store.IterateJournals(ctx, a.chartId, JournalFilter{To: asAt, Nominals: nominals, Order: JournalOrderId})
+	if err != nil {
+		return 0, err
+	}
+	defer it.Close()
+	drAc := *NewAcType().Dr()
+	for it.Next() {
+		for _, entry := range it.Journal().Entries() {
+			if entry.Crcy() == "" || !revalued[*entry.Id()] {
+				continue
+			}
+			if balances[*entry.Id()] == nil {
+				balances[*entry.Id()] = make(map[string]*foreignBalance)
+			}
+			balance := balances[*entry.Id()][entry.Crcy()]
+			if balance == nil {
+				balance = &foreignBalance{nominal: *entry.Id(), crcy: entry.Crcy()}
+				balances[*entry.Id()][entry.Crcy()] = balance
+			}
+			if *entry.Type()&drAc == drAc {
+				balance.crcyAmount += entry.CrcyAmount()
+				balance.amount += entry.Amount()
+			} else {
+				balance.crcyAmount -= entry.CrcyAmount()
+				balance.amount -= entry.Amount()
+			}
+		}
+	}
+	if err = it.Err(); err != nil {
+		return 0, err
+	}
+	if err = it.Close(); err != nil {
+		return 0, err
+	}
+	sorted := make([]*foreignBalance, 0)
+	for _, byCrcy := range balances {
+		for _, balance := range byCrcy {
+			sorted = append(sorted, balance)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].nominal == sorted[j].nominal {
+			return sorted[i].crcy < sorted[j].crcy
+		}
+		return sorted[i].nominal < sorted[j].nominal
+	})
+
+	if len(sorted) == 0 {
+		return 0, nil
+	}
+	crcy, err := a.baseCrcy(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	crAc := *NewAcType().Cr()
+	txn := NewSplitTransactionBuilder(0).
+		WithDate(asAt).
+		WithSource(RevaluationSource).
+		WithReference(dateRef(asAt)).
+		WithNote(fmt.Sprintf("Revaluation at %s", asAt.Format("2006-01-02")))
+	var gain int64
+	adjustments := 0
+	for _, balance := range sorted {
+		rate, ok := rates[balance.crcy]
+		if !ok {
+			if rate, err = a.rateProvider().Rate(ctx, balance.crcy, crcy, asAt); err != nil {
+				return 0, err
+			}
+		}
+		adjustment := convertAmount(balance.crcyAmount, rate, balance.crcy, crcy) - balance.amount
+		switch {
+		case adjustment > 0:
+			txn = txn.WithEntry(*NewEntry(balance.nominal, adjustment, drAc))
+		case adjustment < 0:
+			txn = txn.WithEntry(*NewEntry(balance.nominal, -adjustment, crAc))
+		default:
+			continue
+		}
+		gain += adjustment
+		adjustments++
+	}
+	if adjustments == 0 {
+		return 0, nil
+	}
+	switch {
+	case gain > 0:
+		txn = txn.WithEntry(*NewEntry(gainLoss, gain, crAc))
+	case gain < 0:
+		txn = txn.WithEntry(*NewEntry(gainLoss, -gain, drAc))
+	}
+	if err = a.checkPeriod(ctx, asAt); err != nil {
+		return 0, err
+	}
+	return a.store.WriteJournal(ctx, a.chartId, txn.Build(), asAt)
+}
+
+//previousRevaluation returns the last revaluation journal that has not been reversed, or nil if there is none.
+//The revaluation journals are read from the last, so only those reversed since it are read before it is found
+func (a *Accountant) previousRevaluation(ctx context.Context) (*SplitTransaction, error) {
+	it, err := a.store.IterateJournals(ctx, a.chartId, JournalFilter{Src: RevaluationSource, Descending: true})
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+	for it.Next() {
+		if !it.Journal().IsVoid() {
+			return it.Journal(), nil
+		}
+	}
+	return nil, it.Err()
+}
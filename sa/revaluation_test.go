@@ -0,0 +1,115 @@
+//go:build unit || integration
+// +build unit integration
+
+package sa_test
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"github.com/chippyash/go-simple-accounts/sa"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestAccountant_Revalue(t *testing.T) {
+	bought, _ := time.Parse(time.RFC3339, "2021-01-10T12:00:00Z")
+	january, _ := time.Parse(time.RFC3339, "2021-01-31T23:59:59Z")
+	february, _ := time.Parse(time.RFC3339, "2021-02-28T23:59:59Z")
+	income := sa.Nominal("4000")
+	liabilities := sa.Nominal("2000")
+	for name, accountant := range storeTestAccountants(t) {
+		assert.NoError(t, accountant.AddAccount("4900", sa.NewAcType().Income(), "Exchange Gains", &income), name)
+		assert.NoError(t, accountant.AddAccount("2300", sa.NewAcType().Supplier(), "EU Supplier", &liabilities), name)
+		//EUR 1000.00 received into the savings account, and EUR 200.00 owed to a supplier, at 0.85
+		for _, txn := range []*sa.SplitTransaction{
+			sa.NewSplitTransactionBuilder(0).
+				WithEntry(*sa.NewCurrencyEntry("1220", 85000, *sa.NewAcType().Dr(), "EUR", 100000, 0.85)).
+				WithEntry(*sa.NewEntry("4100", 85000, *sa.NewAcType().Cr())).
+				Build(),
+			sa.NewSplitTransactionBuilder(0).
+				WithEntry(*sa.NewEntry("6400", 17000, *sa.NewAcType().Dr())).
+				WithEntry(*sa.NewCurrencyEntry("2300", 17000, *sa.NewAcType().Cr(), "EUR", 20000, 0.85)).
+				Build(),
+		} {
+			_, err := accountant.WriteTransactionWithDate(txn, bought)
+			assert.NoError(t, err, name)
+		}
+
+		_, err := accountant.Revalue(january, map[string]float64{"USD": 1.2}, "4900")
+		assert.ErrorIs(t, err, sa.ErrNoExchangeRate, name)
+		_, err = accountant.Revalue(january, map[string]float64{"EUR": 0.9}, "9999")
+		assert.ErrorIs(t, err, sa.ErrBadNominal, name)
+		for _, rates := range []map[string]float64{{"EUR": 0}, {"EUR": -0.9}, {"EUR": math.NaN()}, {"EURO": 0.9}} {
+			_, err = accountant.Revalue(january, rates, "4900")
+			assert.ErrorIs(t, err, sa.ErrBadExchangeRate, name)
+		}
+
+		janId, err := accountant.Revalue(january, map[string]float64{"EUR": 0.9}, "4900")
+		assert.NoError(t, err, name)
+		jrn, _ := accountant.FetchTransaction(janId)
+		assert.Equal(t, sa.RevaluationSource, jrn.Src(), name)
+		assert.Equal(t, uint64(20210131), jrn.Ref(), name)
+		assert.Equal(t, 3, len(jrn.Entries()), name)
+		assertBalances(t, accountant, january, map[sa.Nominal]int64{"1220": 90000, "2300": 18000, "4900": 4000}, name)
+
+		//the January revaluation is reversed before revaluing at the February rate
+		febId, err := accountant.Revalue(february, map[string]float64{"EUR": 0.8}, "4900")
+		assert.NoError(t, err, name)
+		jrn, _ = accountant.FetchTransaction(janId)
+		assert.True(t, jrn.IsVoid(), name)
+		jrn, _ = accountant.FetchTransaction(febId)
+		assert.False(t, jrn.IsVoid(), name)
+		assertBalances(t, accountant, february, map[sa.Nominal]int64{"1220": 80000, "2300": 16000, "4900": -4000}, name)
+		assertBalances(t, accountant, january, map[sa.Nominal]int64{"1220": 90000, "2300": 18000, "4900": 4000}, name)
+
+		_, err = accountant.Revalue(january, map[string]float64{"EUR": 0.9}, "4900")
+		assert.ErrorIs(t, err, sa.ErrRevaluationOrder, name)
+
+		//at the original rate there is nothing to revalue, but the February revaluation is still reversed
+		marId, err := accountant.Revalue(february.AddDate(0, 1, 0), map[string]float64{"EUR": 0.85}, "4900")
+		assert.NoError(t, err, name)
+		assert.Equal(t, uint64(0), marId, name)
+		assertBalances(t, accountant, february.AddDate(0, 1, 0), map[sa.Nominal]int64{"1220": 85000, "2300": 17000, "4900": 0}, name)
+	}
+}
+
+func TestAccountant_RevalueByMinorUnits(t *testing.T) {
+	bought, _ := time.Parse(time.RFC3339, "2021-01-10T12:00:00Z")
+	january, _ := time.Parse(time.RFC3339, "2021-01-31T23:59:59Z")
+	february, _ := time.Parse(time.RFC3339, "2021-02-28T23:59:59Z")
+	for name, accountant := range storeTestAccountants(t) {
+		//¥10,000 at 0.0055 is £55.00
+		txn := sa.NewSplitTransactionBuilder(0).
+			WithEntry(*sa.NewCurrencyEntry("1220", 5500, *sa.NewAcType().Dr(), "JPY", 10000, 0.0055)).
+			WithEntry(*sa.NewEntry("4100", 5500, *sa.NewAcType().Cr())).
+			Build()
+		_, err := accountant.WriteTransactionWithDate(txn, bought)
+		assert.NoError(t, err, name)
+
+		//given rates and provider rates are both for whole units
+		_, err = accountant.Revalue(january, map[string]float64{"JPY": 0.006}, "4200")
+		assert.NoError(t, err, name)
+		assertBalances(t, accountant, january, map[sa.Nominal]int64{"1220": 6000}, name)
+
+		assert.NoError(t, accountant.AddExchangeRates([]sa.ExchangeRate{{From: "JPY", To: "GBP", Date: february, Rate: 0.005}}), name)
+		_, err = accountant.Revalue(february, nil, "4200")
+		assert.NoError(t, err, name)
+		assertBalances(t, accountant, february, map[sa.Nominal]int64{"1220": 5000}, name)
+	}
+}
+
+func assertBalances(t *testing.T, accountant *sa.Accountant, dt time.Time, expected map[sa.Nominal]int64, name string) {
+	for nominal, amount := range expected {
+		balance, err := accountant.BalanceAsAt(nominal, dt)
+		assert.NoError(t, err, name)
+		assert.Equal(t, amount, balance, "%s: %s at %s", name, nominal, dt.Format(time.RFC3339))
+	}
+}
@@ -20,6 +20,10 @@ type SplitTransaction struct {
 	src     string
 	ref     uint64
 	entries Entries
+	//reversalOf is the id of the journal that this journal reverses
+	reversalOf uint64
+	//reversedBy is the id of the journal that reverses this journal
+	reversedBy uint64
 }
 
 //Id returns the transaction id
@@ -52,11 +56,36 @@ func (s *SplitTransaction) Entries() Entries {
 	return s.entries
 }
 
+//ReversalOf returns the id of the journal that this transaction reverses, or 0 if it is not a reversal
+func (s *SplitTransaction) ReversalOf() uint64 {
+	return s.reversalOf
+}
+
+//ReversedBy returns the id of the journal that reverses this transaction, or 0 if it has not been reversed
+func (s *SplitTransaction) ReversedBy() uint64 {
+	return s.reversedBy
+}
+
+//IsVoid returns true if the transaction has been reversed
+func (s *SplitTransaction) IsVoid() bool {
+	return s.reversedBy != 0
+}
+
 //CheckBalance returns true if the transaction entries balance else false
 func (s *SplitTransaction) CheckBalance() bool {
 	return s.entries.CheckBalance()
 }
 
+//validCurrencies returns false if an entry has an invalid transaction currency
+func (s *SplitTransaction) validCurrencies() bool {
+	for _, entry := range s.entries {
+		if !entry.validCurrency() {
+			return false
+		}
+	}
+	return true
+}
+
 //GetEntry returns the entry for the nominal code
 func (s *SplitTransaction) GetEntry(nominal Nominal) (*Entry, error) {
 	ret := s.entries.Filter(func(entry *Entry) bool {
@@ -131,6 +160,13 @@ func NewSplitTransactionBuilder(id uint64) *SplitTransactionBuilder {
 	}}
 }
 
+//NewMoneyTransactionBuilder returns a SplitTransactionBuilder with a debit and a credit entry of an amount of Money
+func NewMoneyTransactionBuilder(id uint64, drAc, crAc Nominal, amount Money) *SplitTransactionBuilder {
+	return NewSplitTransactionBuilder(id).
+		WithMoneyEntry(drAc, amount, *NewAcType().Dr()).
+		WithMoneyEntry(crAc, amount, *NewAcType().Cr())
+}
+
 //WithDate adds a date to the builder
 func (b *SplitTransactionBuilder) WithDate(dt time.Time) *SplitTransactionBuilder {
 	b.txn.date = dt
@@ -155,6 +191,18 @@ func (b *SplitTransactionBuilder) WithReference(ref uint64) *SplitTransactionBui
 	return b
 }
 
+//WithReversalOf marks the transaction as the reversal of the journal with id jrnId
+func (b *SplitTransactionBuilder) WithReversalOf(jrnId uint64) *SplitTransactionBuilder {
+	b.txn.reversalOf = jrnId
+	return b
+}
+
+//WithReversedBy marks the transaction as reversed by the journal with id jrnId
+func (b *SplitTransactionBuilder) WithReversedBy(jrnId uint64) *SplitTransactionBuilder {
+	b.txn.reversedBy = jrnId
+	return b
+}
+
 //WithEntries adds a set of Entry to the builder
 func (b *SplitTransactionBuilder) WithEntries(entries Entries) *SplitTransactionBuilder {
 	b.txn.entries = append(b.txn.entries, entries...)
@@ -167,6 +215,12 @@ func (b *SplitTransactionBuilder) WithEntry(entry Entry) *SplitTransactionBuilde
 	return b
 }
 
+//WithMoneyEntry adds a single Entry of an amount of Money to the builder
+func (b *SplitTransactionBuilder) WithMoneyEntry(entryId Nominal, amount Money, tpe AccountType) *SplitTransactionBuilder {
+	b.txn.entries = append(b.txn.entries, NewMoneyEntry(entryId, amount, tpe))
+	return b
+}
+
 //Build builds and returns a SplitTransaction
 func (b *SplitTransactionBuilder) Build() *SplitTransaction {
 	return b.txn
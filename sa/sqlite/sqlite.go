@@ -0,0 +1,38 @@
+package sqlite
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/chippyash/go-simple-accounts/sa"
+	"github.com/mattn/go-sqlite3"
+)
+
+func init() {
+	sa.RegisterMigrations(&sqlite3.SQLiteDriver{}, "sqlite")
+	sa.RegisterStore(&sqlite3.SQLiteDriver{}, func(db *sql.DB) sa.Store { return NewStore(db) })
+}
+
+//Dialect is the SQLite dialect of a sa.SqlStore
+var Dialect = sa.SqlDialect{
+	IsDuplicateKey: isDuplicateKey,
+}
+
+//NewStore returns a Store using a SQLite database built from the db/sqlite schema.
+//The MySql stored routines and trigger are implemented in Go
+func NewStore(db *sql.DB) *sa.SqlStore {
+	return sa.NewSqlStore(db, Dialect)
+}
+
+//isDuplicateKey returns true if err is a SQLite unique index violation
+func isDuplicateKey(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
@@ -0,0 +1,226 @@
+//go:build unit
+// +build unit
+
+package sa_test
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"context"
+	"database/sql"
+	"github.com/chippyash/go-simple-accounts/sa"
+	"github.com/chippyash/go-simple-accounts/sa/sqlite"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var sqliteAccountant *sa.Accountant
+var sqliteDb *sql.DB
+
+func TestSqliteStore_CreateChart(t *testing.T) {
+	setupSqliteStoreTest(t)
+	chart, err := sqliteAccountant.FetchChart()
+	assert.NoError(t, err)
+	assert.Equal(t, "Test", chart.Name())
+	assert.Equal(t, 5, chart.Tree().GetHeight())
+	assert.Equal(t, sa.NewAcType().Real(), chart.Tree().GetValue().(*sa.Account).Type())
+	assert.Equal(t, sa.Nominal("0001"), chart.GetParentId(sa.MustNewNominal("1000")))
+}
+
+func TestSqliteStore_ChartNamesAreUnique(t *testing.T) {
+	setupSqliteStoreTest(t)
+	_, err := sqlite.NewStore(sqliteDb).CreateChart(context.Background(), "Test")
+	assert.ErrorIs(t, err, sa.ErrChartExists)
+}
+
+func TestSqliteStore_LedgersAreStoredAsANestedSet(t *testing.T) {
+	setupSqliteStoreTest(t)
+	var cnt, lft, rgt int
+	err := sqliteDb.QueryRow("select count(id) from sa_coa_ledger").Scan(&cnt)
+	assert.NoError(t, err)
+	err = sqliteDb.QueryRow("select lft, rgt from sa_coa_ledger where nominal = '0000'").Scan(&lft, &rgt)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, lft)
+	assert.Equal(t, cnt*2, rgt)
+
+	//6120 Garden has three children
+	err = sqliteDb.QueryRow("select lft, rgt from sa_coa_ledger where nominal = '6120'").Scan(&lft, &rgt)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, rgt-lft)
+	err = sqliteDb.QueryRow("select count(id) from sa_coa_ledger where lft > ? and rgt < ?", lft, rgt).Scan(&cnt)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, cnt)
+}
+
+func TestSqliteStore_WriteTransactionRollsUpParentLedgers(t *testing.T) {
+	setupSqliteStoreTest(t)
+	dt, _ := time.Parse(time.RFC3339, "2020-08-05T14:36:00+01:00")
+	txn := sa.NewSimpleTransactionBuilder(0, "1210", "4100", 100).Build()
+	_, err := sqliteAccountant.WriteTransactionWithDate(txn, dt)
+	assert.NoError(t, err)
+	txn = sa.NewSimpleTransactionBuilder(0, "6120", "1210", 10).Build()
+	_, err = sqliteAccountant.WriteTransactionWithDate(txn, dt)
+	assert.NoError(t, err)
+
+	values := map[string][]int64{
+		"0000": {110, 110},
+		"0001": {100, 10},
+		"1000": {100, 10},
+		"1100": {100, 10},
+		"1200": {100, 10},
+		"1210": {100, 10},
+		"1220": {0, 0},
+		"0002": {10, 100},
+		"4000": {0, 100},
+		"4100": {0, 100},
+		"6000": {10, 0},
+		"6100": {10, 0},
+		"6120": {10, 0},
+		"6121": {0, 0},
+	}
+	chart, _ := sqliteAccountant.FetchChart()
+	for nom, vals := range values {
+		ac := chart.GetAccount(sa.MustNewNominal(nom))
+		assert.Equal(t, vals[0], ac.Dr(), "DR a/c value: %d not equal %d for Nominal: %s", ac.Dr(), vals[0], nom)
+		assert.Equal(t, vals[1], ac.Cr(), "CR a/c value: %d not equal %d for Nominal: %s", ac.Cr(), vals[1], nom)
+	}
+}
+
+func TestSqliteStore_WriteUnbalancedTransaction(t *testing.T) {
+	setupSqliteStoreTest(t)
+	entry := sa.NewEntry("0001", 100, *sa.NewAcType().Dr())
+	txn := sa.NewSplitTransactionBuilder(0).
+		WithEntries(sa.Entries{entry, entry}).
+		Build()
+	_, err := sqliteAccountant.WriteTransaction(txn)
+	assert.ErrorIs(t, err, sa.ErrUnbalancedTransaction)
+}
+
+func TestSqliteStore_CancelledContext(t *testing.T) {
+	setupSqliteStoreTest(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	txn := sa.NewSimpleTransactionBuilder(0, "1210", "4100", 100).Build()
+	_, err := sqliteAccountant.WriteTransactionContext(ctx, txn)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = sqliteAccountant.FetchChartContext(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestSqliteStore_FetchTransaction(t *testing.T) {
+	setupSqliteStoreTest(t)
+	dt, _ := time.Parse(time.RFC3339, "2020-08-05T14:36:00+01:00")
+	txn := sa.NewSimpleTransactionBuilder(0, "1210", "4100", 100).
+		WithNote("foo").
+		WithSource("PAY").
+		WithReference(2).
+		Build()
+	jrnId, _ := sqliteAccountant.WriteTransactionWithDate(txn, dt)
+
+	journal, err := sqliteAccountant.FetchTransaction(jrnId)
+	assert.NoError(t, err)
+	assert.Equal(t, jrnId, journal.Id())
+	assert.Equal(t, "2020-08-05T13:36:00Z", journal.Date().Format(time.RFC3339))
+	assert.Equal(t, "foo", journal.Note())
+	assert.Equal(t, "PAY", journal.Src())
+	assert.Equal(t, uint64(2), journal.Ref())
+	assert.Equal(t, 2, len(journal.Entries()))
+	assert.True(t, journal.CheckBalance())
+
+	_, err = sqliteAccountant.FetchTransaction(jrnId + 1)
+	assert.ErrorIs(t, err, sa.ErrJournalNotFound)
+}
+
+func TestSqliteStore_FetchAccountJournals(t *testing.T) {
+	setupSqliteStoreTest(t)
+	txn := sa.NewSimpleTransactionBuilder(0, "1210", "4100", 100).Build()
+	_, _ = sqliteAccountant.WriteTransaction(txn)
+	txn = sa.NewSimpleTransactionBuilder(0, "6120", "1210", 10).Build()
+	_, _ = sqliteAccountant.WriteTransaction(txn)
+
+	journals, err := sqliteAccountant.FetchAccountJournals("1210")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(journals))
+	assert.Equal(t, 1, len(journals[0].Entries()))
+	assert.Equal(t, 1, len(journals[1].Entries()))
+	assert.Equal(t, []*sa.Nominal{journals[1].Entries()[0].Id()}, journals[1].GetCrAc())
+}
+
+func TestSqliteStore_AddAccount(t *testing.T) {
+	setupSqliteStoreTest(t)
+
+	//2nd root account - error
+	nom := sa.MustNewNominal("1111")
+	err := sqliteAccountant.AddAccount(nom, sa.NewAcType().Asset(), "foo", nil)
+	assert.ErrorIs(t, err, sa.ErrRootExists)
+
+	//parent doesn't exist - error
+	prnt := sa.MustNewNominal("9999")
+	err = sqliteAccountant.AddAccount(nom, sa.NewAcType().Asset(), "foo", &prnt)
+	assert.ErrorIs(t, err, sa.ErrBadParent)
+
+	//valid insertion
+	prnt = sa.MustNewNominal("0000")
+	err = sqliteAccountant.AddAccount(nom, sa.NewAcType().Asset(), "foo", &prnt)
+	assert.NoError(t, err)
+
+	//duplicate nominal - error
+	err = sqliteAccountant.AddAccount(nom, sa.NewAcType().Asset(), "foo", &prnt)
+	assert.ErrorIs(t, err, sa.ErrNominalExists)
+}
+
+func TestSqliteStore_DelAccount(t *testing.T) {
+	setupSqliteStoreTest(t)
+	txn := sa.NewSimpleTransactionBuilder(0, "1210", "4100", 100).Build()
+	_, _ = sqliteAccountant.WriteTransaction(txn)
+
+	//attempt to delete account with non-zero balance - error
+	err := sqliteAccountant.DelAccount(sa.MustNewNominal("1200"))
+	assert.ErrorIs(t, err, sa.ErrNonZeroBalance)
+
+	//zero balance - ok, and child accounts are deleted
+	err = sqliteAccountant.DelAccount(sa.MustNewNominal("6100"))
+	assert.NoError(t, err)
+	chart, _ := sqliteAccountant.FetchChart()
+	assert.False(t, chart.HasAccount(sa.MustNewNominal("6100")))
+	assert.False(t, chart.HasAccount(sa.MustNewNominal("6123")))
+	assert.True(t, chart.HasAccount(sa.MustNewNominal("6200")))
+}
+
+func TestSqliteStore_NextNominal(t *testing.T) {
+	setupSqliteStoreTest(t)
+	starter := sa.MustNewNominal("1801")
+	prnt := sa.MustNewNominal("1800")
+
+	next, err := sqliteAccountant.NextNominal(prnt, starter)
+	assert.NoError(t, err)
+	assert.Equal(t, "1801", next.String())
+
+	err = sqliteAccountant.AddAccount(starter, sa.NewAcType().Asset(), "Tractor", &prnt)
+	assert.NoError(t, err)
+
+	next, err = sqliteAccountant.NextNominal(prnt, starter)
+	assert.NoError(t, err)
+	assert.Equal(t, "1802", next.String())
+}
+
+func setupSqliteStoreTest(t *testing.T) {
+	dba, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "sa.db")+"?_foreign_keys=1")
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = dba.Close() })
+	assert.NoError(t, sa.Migrate(dba))
+	sqliteDb = dba
+	sqliteAccountant = sa.NewAccountant(sqlite.NewStore(dba), 0, "GBP")
+	def, err := sa.NewChartDefinition("../tests/_data/personal.xml")
+	assert.NoError(t, err)
+	_, err = sqliteAccountant.CreateChart("Test", "GBP", def)
+	assert.NoError(t, err)
+}
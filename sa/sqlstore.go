@@ -0,0 +1,621 @@
+package sa
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//SqlDialect is the SQL differences between the databases held by a SqlStore, and the errors of their drivers.
+//The sa/sqlite and sa/postgres packages provide the dialects for SQLite and PostgreSQL
+type SqlDialect struct {
+	//NumberedParams is true if bind parameters are written $1, $2 ..., and inserts return their id with returning id
+	NumberedParams bool
+	//IsDuplicateKey returns true if err is a unique index violation
+	IsDuplicateKey func(err error) bool
+}
+
+//SqlStore implements the MySql stored routines and trigger in Go, for databases that
+//only hold the Simple Accounts tables. Dates are stored as UTC
+type SqlStore struct {
+	db      *sql.DB
+	tx      *sql.Tx
+	dialect SqlDialect
+}
+
+//NewSqlStore returns a Store for a database with the dialect, built from the schema for that dialect.
+//Use the constructors in the sa/sqlite and sa/postgres packages
+func NewSqlStore(db *sql.DB, dialect SqlDialect) *SqlStore {
+	return &SqlStore{db: db, dialect: dialect}
+}
+
+//sqlTxStore is a SqlStore bound to a database transaction
+type sqlTxStore struct {
+	*SqlStore
+}
+
+//Begin starts a database transaction and returns a Store bound to it
+func (s *SqlStore) Begin(ctx context.Context) (TxStore, error) {
+	if s.tx != nil {
+		return nil, ErrNestedTransaction
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &sqlTxStore{SqlStore: &SqlStore{db: s.db, tx: tx, dialect: s.dialect}}, nil
+}
+
+//Commit commits the transaction
+func (s *sqlTxStore) Commit() error {
+	return s.tx.Commit()
+}
+
+//Rollback rolls back the transaction
+func (s *sqlTxStore) Rollback() error {
+	return s.tx.Rollback()
+}
+
+//conn returns the transaction that the store is bound to, else the database
+func (s *SqlStore) conn() dbtx {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
+
+//withTx runs f within the transaction that the store is bound to, else within a new transaction
+func (s *SqlStore) withTx(ctx context.Context, f func(tx dbtx) error) error {
+	if s.tx != nil {
+		return f(s.tx)
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err = f(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+//q rewrites ? bind parameters into the form used by the dialect
+func (s *SqlStore) q(query string) string {
+	if !s.dialect.NumberedParams {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+//dbtx is the part of sql.DB and sql.Tx used by the Stores
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+//insert runs an insert statement and returns the id of the inserted row
+func (s *SqlStore) insert(ctx context.Context, db dbtx, query string, args ...interface{}) (uint64, error) {
+	if s.dialect.NumberedParams {
+		var id uint64
+		err := db.QueryRowContext(ctx, s.q(query+" returning id"), args...).Scan(&id)
+		return id, err
+	}
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	lastId, err := res.LastInsertId()
+	return uint64(lastId), err
+}
+
+//SchemaVersion returns the version of the database schema, and whether the last migration to it failed
+func (s *SqlStore) SchemaVersion(ctx context.Context) (uint, bool, error) {
+	return readSchemaVersion(ctx, s.conn())
+}
+
+//CreateChart stores a new chart and returns its id
+func (s *SqlStore) CreateChart(ctx context.Context, name string) (uint64, error) {
+	var cnt int
+	err := s.conn().QueryRowContext(ctx, s.q("select count(id) from sa_coa where name = ?"), name).Scan(&cnt)
+	if err != nil {
+		return 0, err
+	}
+	if cnt > 0 {
+		return 0, ErrChartExists
+	}
+	return s.insert(ctx, s.conn(), "insert into sa_coa (name, created) values (?, ?)", name, time.Now().UTC())
+}
+
+//FetchChartName returns the name of a chart
+func (s *SqlStore) FetchChartName(ctx context.Context, chartId uint64) (string, error) {
+	var chartName string
+	err := s.conn().QueryRowContext(ctx, s.q("select name from sa_coa where id = ?"), chartId).Scan(&chartName)
+	if err == sql.ErrNoRows {
+		return "", ErrNoChartName
+	}
+	return chartName, err
+}
+
+//FetchChartInfo returns the name and metadata of a chart
+func (s *SqlStore) FetchChartInfo(ctx context.Context, chartId uint64) (*ChartInfo, error) {
+	return scanChartInfo(s.conn().QueryRowContext(ctx, s.q("select "+chartInfoColumns+" from sa_coa where id = ?"), chartId))
+}
+
+//SetChartInfo sets the currency, description and fiscal year start of a chart
+func (s *SqlStore) SetChartInfo(ctx context.Context, chartId uint64, info ChartInfo) error {
+	res, err := s.conn().ExecContext(ctx,
+		s.q("update sa_coa set crcy = ?, description = ?, fiscalYearStart = ? where id = ?"),
+		nullCrcy(info.Crcy), info.Description, nullTime(info.FiscalYearStart), chartId,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n != 1 {
+		return ErrChartNotFound
+	}
+	return nil
+}
+
+//ListCharts returns the name and metadata of every chart, ordered by id
+func (s *SqlStore) ListCharts(ctx context.Context) ([]ChartInfo, error) {
+	return listCharts(ctx, s.conn())
+}
+
+//RenameChart renames a chart
+func (s *SqlStore) RenameChart(ctx context.Context, chartId uint64, name string) error {
+	return s.withTx(ctx, func(tx dbtx) error {
+		var cnt int
+		err := tx.QueryRowContext(ctx, s.q("select count(id) from sa_coa where name = ? and id <> ?"), name, chartId).Scan(&cnt)
+		if err != nil {
+			return err
+		}
+		if cnt > 0 {
+			return ErrChartExists
+		}
+		res, err := tx.ExecContext(ctx, s.q("update sa_coa set name = ? where id = ?"), name, chartId)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil || n != 1 {
+			return ErrChartNotFound
+		}
+		return nil
+	})
+}
+
+//DeleteChart deletes a chart with its ledgers, journals and periods.
+//The rows are deleted explicitly, as SQLite only cascades the foreign keys when they are turned on
+func (s *SqlStore) DeleteChart(ctx context.Context, chartId uint64) error {
+	return s.withTx(ctx, func(tx dbtx) error {
+		for _, query := range []string{
+			"delete from sa_journal_entry where jrnId in (select id from sa_journal where chartId = ?)",
+			"delete from sa_journal where chartId = ?",
+			"delete from sa_period where chartId = ?",
+			"delete from sa_coa_ledger where chartId = ?",
+		} {
+			if _, err := tx.ExecContext(ctx, s.q(query), chartId); err != nil {
+				return err
+			}
+		}
+		res, err := tx.ExecContext(ctx, s.q("delete from sa_coa where id = ?"), chartId)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil || n != 1 {
+			return ErrChartNotFound
+		}
+		return nil
+	})
+}
+
+//AddLedger adds a ledger to a chart, maintaining the nested set in the same way as sa_sp_add_ledger
+func (s *SqlStore) AddLedger(ctx context.Context, chartId uint64, nominal Nominal, tpe *AccountType, name string, prnt Nominal) error {
+	acType, ok := GetValuedAccountTypes()[*tpe]
+	if !ok {
+		return ErrBadAccountType
+	}
+	return s.withTx(ctx, func(tx dbtx) error {
+		var cnt int
+		err := tx.QueryRowContext(ctx, s.q("select count(id) from sa_coa_ledger where chartId = ? and nominal = ?"), chartId, nominal.String()).Scan(&cnt)
+		if err != nil {
+			return err
+		}
+		if cnt > 0 {
+			return ErrNominalExists
+		}
+
+		insert := "insert into sa_coa_ledger (prntId, lft, rgt, chartId, nominal, type, name) values (?, ?, ?, ?, ?, ?, ?)"
+		if prnt == "" {
+			//check to see if we already have a root account
+			err = tx.QueryRowContext(ctx, s.q("select count(id) from sa_coa_ledger where prntId = 0 and chartId = ?"), chartId).Scan(&cnt)
+			if err != nil {
+				return err
+			}
+			if cnt > 0 {
+				return ErrRootExists
+			}
+			_, err = tx.ExecContext(ctx, s.q(insert), 0, 1, 2, chartId, nominal.String(), acType, name)
+			return err
+		}
+
+		var prntId, prntLft uint64
+		err = tx.QueryRowContext(ctx, s.q("select id, lft from sa_coa_ledger where nominal = ? and chartId = ?"), prnt.String(), chartId).Scan(&prntId, &prntLft)
+		if err == sql.ErrNoRows {
+			return ErrBadParent
+		}
+		if err != nil {
+			return err
+		}
+
+		//insert to the right of the last child, or as the first child of the parent
+		var rightChild sql.NullInt64
+		err = tx.QueryRowContext(ctx, s.q("select max(id) from sa_coa_ledger where prntId = ? and chartId = ?"), prntId, chartId).Scan(&rightChild)
+		if err != nil {
+			return err
+		}
+		edge := prntLft
+		if rightChild.Valid {
+			err = tx.QueryRowContext(ctx, s.q("select rgt from sa_coa_ledger where id = ?"), rightChild.Int64).Scan(&edge)
+			if err != nil {
+				return err
+			}
+		}
+		_, err = tx.ExecContext(ctx, s.q("update sa_coa_ledger set rgt = rgt + 2 where rgt > ? and chartId = ?"), edge, chartId)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, s.q("update sa_coa_ledger set lft = lft + 2 where lft > ? and chartId = ?"), edge, chartId)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, s.q(insert), prntId, edge+1, edge+2, chartId, nominal.String(), acType, name)
+		return err
+	})
+}
+
+//DelLedger deletes a ledger and all of its descendant ledgers
+func (s *SqlStore) DelLedger(ctx context.Context, chartId uint64, nominal Nominal) error {
+	return s.withTx(ctx, func(tx dbtx) error {
+		var lft, rgt uint64
+		var acDr, acCr int64
+		err := tx.QueryRowContext(ctx, s.q("select lft, rgt, acDr, acCr from sa_coa_ledger where nominal = ? and chartId = ?"), nominal.String(), chartId).
+			Scan(&lft, &rgt, &acDr, &acCr)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if acDr > 0 || acCr > 0 {
+			return ErrNonZeroBalance
+		}
+		_, err = tx.ExecContext(ctx, s.q("delete from sa_coa_ledger where lft between ? and ? and chartId = ?"), lft, rgt, chartId)
+		return err
+	})
+}
+
+//FetchLedgers returns the ledgers of a chart
+func (s *SqlStore) FetchLedgers(ctx context.Context, chartId uint64) (Ledgers, error) {
+	res, err := s.conn().QueryContext(ctx,
+		s.q("select prntId, id, nominal, name, type, acDr, acCr, chartId from sa_coa_ledger where chartId = ? order by prntId, id"),
+		chartId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+	ledgers := make(Ledgers, 0)
+	for res.Next() {
+		l := Ledger{}
+		err = res.Scan(&l.PrntId, &l.Id, &l.Nominal, &l.Name, &l.Tpe, &l.AcDr, &l.AcCr, &l.ChartId)
+		if err != nil {
+			return nil, err
+		}
+		ledgers = append(ledgers, l)
+	}
+	return ledgers, res.Err()
+}
+
+//WriteJournal stores a journal and rolls each entry up through the ledger and its parents,
+//in the same way as the sp_tr_jrn_entry_updt trigger. A reversal journal voids the journal that it reverses
+func (s *SqlStore) WriteJournal(ctx context.Context, chartId uint64, txn *SplitTransaction, dt time.Time) (uint64, error) {
+	return s.writeJournal(ctx, chartId, txn, dt, false)
+}
+
+//WriteKeyedJournal stores a journal with its src and ref as a key that is unique within the chart
+func (s *SqlStore) WriteKeyedJournal(ctx context.Context, chartId uint64, txn *SplitTransaction, dt time.Time) (uint64, error) {
+	return s.writeJournal(ctx, chartId, txn, dt, true)
+}
+
+func (s *SqlStore) writeJournal(ctx context.Context, chartId uint64, txn *SplitTransaction, dt time.Time, keyed bool) (uint64, error) {
+	var jrnId uint64
+	write := func(tx dbtx) error {
+		var err error
+		jrnId, err = s.insert(
+			ctx,
+			tx,
+			"insert into sa_journal (chartId, note, date, src, ref, reversalOf, srcKey) values (?, ?, ?, ?, ?, ?, ?)",
+			chartId, txn.Note(), dt.UTC(), txn.Src(), txn.Ref(), nullId(txn.ReversalOf()), nullKey(keyed),
+		)
+		if keyed && s.dialect.IsDuplicateKey != nil && s.dialect.IsDuplicateKey(err) {
+			return ErrDuplicateJournal
+		}
+		if err != nil {
+			return err
+		}
+		if txn.ReversalOf() != 0 {
+			res, err := tx.ExecContext(ctx,
+				s.q("update sa_journal set reversedBy = ? where id = ? and chartId = ? and reversedBy is null"),
+				jrnId, txn.ReversalOf(), chartId,
+			)
+			if err != nil {
+				return err
+			}
+			if n, err := res.RowsAffected(); err != nil || n != 1 {
+				return ErrJournalVoid
+			}
+		}
+
+		for _, entry := range txn.Entries() {
+			e := newStoredEntry(*entry)
+			_, err = tx.ExecContext(ctx,
+				s.q("insert into sa_journal_entry (jrnId, nominal, acDr, acCr, crcy, crcyAmount, rate) values (?, ?, ?, ?, ?, ?, ?)"),
+				jrnId, e.nominal.String(), e.acDr, e.acCr, nullCrcy(e.crcy), e.crcyAmount, e.rate,
+			)
+			if err != nil {
+				return err
+			}
+			_, err = tx.ExecContext(ctx, s.q(`
+update sa_coa_ledger
+set acDr = acDr + ?, acCr = acCr + ?
+where chartId = ?
+  and lft <= (select lft from sa_coa_ledger where chartId = ? and nominal = ?)
+  and rgt >= (select rgt from sa_coa_ledger where chartId = ? and nominal = ?)
+`),
+				e.acDr, e.acCr, chartId, chartId, e.nominal.String(), chartId, e.nominal.String(),
+			)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+	//a failed insert aborts a Postgres transaction, so a duplicate key within a bound transaction
+	//must be rolled back to leave the transaction usable
+	if keyed && s.tx != nil {
+		write = inSavepoint(ctx, write)
+	}
+	err := s.withTx(ctx, write)
+	return jrnId, err
+}
+
+//FetchJournal returns a journal and all of its entries
+func (s *SqlStore) FetchJournal(ctx context.Context, chartId, jrnId uint64) (*SplitTransaction, error) {
+	var note, src string
+	var dt time.Time
+	var ref, reversalOf, reversedBy uint64
+	err := s.conn().QueryRowContext(ctx, s.q("select note, date, src, ref, coalesce(reversalOf, 0), coalesce(reversedBy, 0) from sa_journal where id = ? and chartId = ?"), jrnId, chartId).
+		Scan(&note, &dt, &src, &ref, &reversalOf, &reversedBy)
+	if err == sql.ErrNoRows {
+		return nil, ErrJournalNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	journal := NewSplitTransactionBuilder(jrnId).
+		WithDate(dt).
+		WithNote(note).
+		WithReference(ref).
+		WithSource(src).
+		WithReversalOf(reversalOf).
+		WithReversedBy(reversedBy)
+
+	res, err := s.conn().QueryContext(ctx, s.q("select "+entryColumns("e")+" from sa_journal_entry as e where e.jrnId = ? order by e.id"), jrnId)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+	for res.Next() {
+		var e storedEntry
+		err = res.Scan(e.dest()...)
+		if err != nil {
+			return nil, err
+		}
+		journal = journal.WithEntry(*e.entry())
+	}
+
+	return journal.Build(), res.Err()
+}
+
+//FetchAccountJournals returns the journals for a ledger
+func (s *SqlStore) FetchAccountJournals(ctx context.Context, chartId uint64, nominal Nominal) ([]*SplitTransaction, error) {
+	complexSelect := `
+select j.id, j.note, j.date, j.src, j.ref, coalesce(j.reversalOf, 0), coalesce(j.reversedBy, 0), ` + entryColumns("e") + `
+from sa_journal as j
+join sa_journal_entry as e
+on j.id = e.jrnId
+where e.nominal = ? and j.chartId = ?
+order by j.id
+`
+	res, err := s.conn().QueryContext(ctx, s.q(complexSelect), nominal.String(), chartId)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+	response := make([]*SplitTransaction, 0)
+	var id, ref, reversalOf, reversedBy uint64
+	var note, src string
+	var date time.Time
+	for res.Next() {
+		var e storedEntry
+		err = res.Scan(append([]interface{}{&id, &note, &date, &src, &ref, &reversalOf, &reversedBy}, e.dest()...)...)
+		if err != nil {
+			return nil, err
+		}
+		journal := NewSplitTransactionBuilder(id).
+			WithNote(note).
+			WithDate(date).
+			WithSource(src).
+			WithReference(ref).
+			WithReversalOf(reversalOf).
+			WithReversedBy(reversedBy).
+			WithEntry(*e.entry()).
+			Build()
+		response = append(response, journal)
+	}
+
+	return response, res.Err()
+}
+
+//QueryJournals returns the journals selected by the filter, with all their entries
+func (s *SqlStore) QueryJournals(ctx context.Context, chartId uint64, filter JournalFilter) ([]*SplitTransaction, error) {
+	return queryJournals(ctx, s.conn(), s.q, chartId, filter)
+}
+
+//IterateJournals returns an iterator over the journals selected by the filter, reading each journal and its
+//entries from a single ordered join
+func (s *SqlStore) IterateJournals(ctx context.Context, chartId uint64, filter JournalFilter) (JournalIterator, error) {
+	return iterateJournals(ctx, s.conn(), s.q, chartId, filter)
+}
+
+//AddPeriods adds accounting periods to a chart and returns them with their ids
+func (s *SqlStore) AddPeriods(ctx context.Context, chartId uint64, periods []Period) ([]Period, error) {
+	added := make([]Period, len(periods))
+	err := s.withTx(ctx, func(tx dbtx) error {
+		for i, p := range periods {
+			id, err := s.insert(ctx, tx,
+				"insert into sa_period (chartId, name, startDate, endDate, status) values (?, ?, ?, ?, ?)",
+				chartId, p.Name, p.Start.UTC(), p.End.UTC(), string(p.Status),
+			)
+			if err != nil {
+				return err
+			}
+			p.Id = id
+			p.Start, p.End = p.Start.UTC(), p.End.UTC()
+			added[i] = p
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return added, nil
+}
+
+//FetchPeriods returns the accounting periods of a chart in date order
+func (s *SqlStore) FetchPeriods(ctx context.Context, chartId uint64) ([]Period, error) {
+	res, err := s.conn().QueryContext(ctx, s.q("select id, name, startDate, endDate, status from sa_period where chartId = ? order by startDate"), chartId)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+	periods := make([]Period, 0)
+	for res.Next() {
+		p := Period{}
+		if err = res.Scan(&p.Id, &p.Name, &p.Start, &p.End, &p.Status); err != nil {
+			return nil, err
+		}
+		p.Start, p.End = p.Start.UTC(), p.End.UTC()
+		periods = append(periods, p)
+	}
+	return periods, res.Err()
+}
+
+//SetPeriodStatus sets the status of an accounting period
+func (s *SqlStore) SetPeriodStatus(ctx context.Context, chartId, periodId uint64, status PeriodStatus) error {
+	res, err := s.conn().ExecContext(ctx, s.q("update sa_period set status = ? where id = ? and chartId = ?"), string(status), periodId, chartId)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n != 1 {
+		return ErrPeriodNotFound
+	}
+	return nil
+}
+
+//AddExchangeRates stores exchange rates, replacing any rate for the same currency pair and date
+func (s *SqlStore) AddExchangeRates(ctx context.Context, rates []ExchangeRate) error {
+	return s.withTx(ctx, func(tx dbtx) error {
+		for _, rate := range rates {
+			_, err := tx.ExecContext(ctx,
+				s.q("insert into sa_fx_rate (fromCrcy, toCrcy, rateDate, rate) values (?, ?, ?, ?) on conflict (fromCrcy, toCrcy, rateDate) do update set rate = excluded.rate"),
+				rate.From, rate.To, rate.Date.UTC(), rate.Rate,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+//FetchExchangeRate returns the latest rate for the currency pair dated on or before dt
+func (s *SqlStore) FetchExchangeRate(ctx context.Context, from, to string, dt time.Time) (*ExchangeRate, error) {
+	rate := &ExchangeRate{}
+	err := s.conn().QueryRowContext(ctx,
+		s.q("select fromCrcy, toCrcy, rateDate, rate from sa_fx_rate where fromCrcy = ? and toCrcy = ? and rateDate <= ? order by rateDate desc limit 1"),
+		from, to, dt.UTC(),
+	).Scan(&rate.From, &rate.To, &rate.Date, &rate.Rate)
+	if err == sql.ErrNoRows {
+		return nil, ErrNoExchangeRate
+	}
+	if err != nil {
+		return nil, err
+	}
+	rate.Date = rate.Date.UTC()
+	return rate, nil
+}
+
+//FetchLedgerTotals returns the sums of the journal entries for each ledger, for journals dated between from and to
+func (s *SqlStore) FetchLedgerTotals(ctx context.Context, chartId uint64, from, to time.Time) ([]LedgerTotal, error) {
+	query := `
+select e.nominal, sum(e.acDr), sum(e.acCr)
+from sa_journal as j
+join sa_journal_entry as e
+on j.id = e.jrnId
+where j.chartId = ? and j.date <= ?
+`
+	args := []interface{}{chartId, to.UTC()}
+	if !from.IsZero() {
+		query += "and j.date >= ?\n"
+		args = append(args, from.UTC())
+	}
+	query += "group by e.nominal"
+	res, err := s.conn().QueryContext(ctx, s.q(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+	totals := make([]LedgerTotal, 0)
+	for res.Next() {
+		t := LedgerTotal{}
+		if err = res.Scan(&t.Nominal, &t.AcDr, &t.AcCr); err != nil {
+			return nil, err
+		}
+		totals = append(totals, t)
+	}
+	return totals, res.Err()
+}
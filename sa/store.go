@@ -0,0 +1,321 @@
+package sa
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"reflect"
+	"sync"
+	"time"
+)
+
+//storeFactories make the Store for a database, by the type of the driver that it was opened with
+var (
+	storeFactoriesMu sync.RWMutex
+	storeFactories   = map[reflect.Type]func(db *sql.DB) Store{
+		reflect.TypeOf(&mysql.MySQLDriver{}): func(db *sql.DB) Store { return NewMysqlStore(db) },
+	}
+)
+
+//RegisterStore sets the function that makes the Store for databases opened with the driver.
+//The sa/sqlite and sa/postgres packages register their stores when they are imported
+func RegisterStore(drv driver.Driver, newStore func(db *sql.DB) Store) {
+	storeFactoriesMu.Lock()
+	defer storeFactoriesMu.Unlock()
+	storeFactories[reflect.TypeOf(drv)] = newStore
+}
+
+//NewDbStore returns the Store for a database, chosen by the driver that db was opened with.
+//ErrUnknownDriver is returned for a SQLite or PostgreSQL database unless the sa/sqlite or sa/postgres package is imported
+func NewDbStore(db *sql.DB) (Store, error) {
+	storeFactoriesMu.RLock()
+	newStore, ok := storeFactories[reflect.TypeOf(db.Driver())]
+	storeFactoriesMu.RUnlock()
+	if !ok {
+		return nil, ErrUnknownDriver
+	}
+	return newStore(db), nil
+}
+
+//Store is the storage backend used by an Accountant.
+//A single Store can hold many charts, so every operation is given the chart id it applies to.
+//The context is passed through to the underlying database calls
+type Store interface {
+	//CreateChart stores a new, empty, chart and returns its id
+	CreateChart(ctx context.Context, name string) (uint64, error)
+	//FetchChartName returns the name of a chart
+	FetchChartName(ctx context.Context, chartId uint64) (string, error)
+	//FetchChartInfo returns the name and metadata of a chart. ErrNoChartName is returned if there is no chart
+	FetchChartInfo(ctx context.Context, chartId uint64) (*ChartInfo, error)
+	//SetChartInfo sets the currency, description and fiscal year start of a chart
+	SetChartInfo(ctx context.Context, chartId uint64, info ChartInfo) error
+	//ListCharts returns the name and metadata of every chart, ordered by id
+	ListCharts(ctx context.Context) ([]ChartInfo, error)
+	//RenameChart renames a chart. ErrChartExists is returned if another chart has the name
+	RenameChart(ctx context.Context, chartId uint64, name string) error
+	//DeleteChart deletes a chart with its ledgers, journals and periods
+	DeleteChart(ctx context.Context, chartId uint64) error
+	//AddLedger adds a ledger to a chart. prnt is empty when adding the root ledger
+	AddLedger(ctx context.Context, chartId uint64, nominal Nominal, tpe *AccountType, name string, prnt Nominal) error
+	//DelLedger deletes a ledger and its child ledgers. The ledger must have zero debit and credit values
+	DelLedger(ctx context.Context, chartId uint64, nominal Nominal) error
+	//FetchLedgers returns the ledgers of a chart, ordered by parent id then id, so that the root ledger is first
+	FetchLedgers(ctx context.Context, chartId uint64) (Ledgers, error)
+	//WriteJournal stores a journal and its entries, updating the ledger values, and returns the journal id.
+	//If the journal is a reversal, the journal that it reverses is marked as reversed by it, or ErrJournalVoid
+	//is returned if that journal is already void
+	WriteJournal(ctx context.Context, chartId uint64, txn *SplitTransaction, dt time.Time) (uint64, error)
+	//WriteKeyedJournal stores a journal in the same way as WriteJournal, with its src and ref as a key that is
+	//unique within the chart. ErrDuplicateJournal is returned if a keyed journal with the same src and ref exists
+	WriteKeyedJournal(ctx context.Context, chartId uint64, txn *SplitTransaction, dt time.Time) (uint64, error)
+	//FetchJournal returns a journal and all of its entries
+	FetchJournal(ctx context.Context, chartId, jrnId uint64) (*SplitTransaction, error)
+	//FetchAccountJournals returns the journals for a ledger in id order, each holding only the entry for that ledger
+	FetchAccountJournals(ctx context.Context, chartId uint64, nominal Nominal) ([]*SplitTransaction, error)
+	//QueryJournals returns the journals selected by the filter, with all their entries, in the filter order.
+	//Only the journals after the filter cursor are returned, up to the filter limit
+	QueryJournals(ctx context.Context, chartId uint64, filter JournalFilter) ([]*SplitTransaction, error)
+	//IterateJournals returns an iterator over the journals selected by the filter, with all their entries,
+	//in the filter order. The filter limit is the total number of journals returned, or all of them if it is zero
+	IterateJournals(ctx context.Context, chartId uint64, filter JournalFilter) (JournalIterator, error)
+	//FetchLedgerTotals returns the sums of the journal entries for each ledger of a chart, for journals
+	//dated between from and to inclusive. A zero from includes all journals up to to.
+	//The totals are for the ledger's own entries only, they are not rolled up to parent ledgers
+	FetchLedgerTotals(ctx context.Context, chartId uint64, from, to time.Time) ([]LedgerTotal, error)
+	//AddPeriods adds accounting periods to a chart and returns them with their ids
+	AddPeriods(ctx context.Context, chartId uint64, periods []Period) ([]Period, error)
+	//FetchPeriods returns the accounting periods of a chart in date order
+	FetchPeriods(ctx context.Context, chartId uint64) ([]Period, error)
+	//SetPeriodStatus sets the status of an accounting period. ErrPeriodNotFound is returned if it is not in the chart
+	SetPeriodStatus(ctx context.Context, chartId, periodId uint64, status PeriodStatus) error
+	//AddExchangeRates stores exchange rates, replacing any rate for the same currency pair and date
+	AddExchangeRates(ctx context.Context, rates []ExchangeRate) error
+	//FetchExchangeRate returns the latest rate for the currency pair dated on or before dt.
+	//ErrNoExchangeRate is returned if there is none
+	FetchExchangeRate(ctx context.Context, from, to string, dt time.Time) (*ExchangeRate, error)
+	//Begin starts a transaction and returns a Store bound to it. A Store that is already bound to a
+	//transaction returns ErrNestedTransaction
+	Begin(ctx context.Context) (TxStore, error)
+	//SchemaVersion returns the version of the database schema, and whether the last migration to it failed
+	SchemaVersion(ctx context.Context) (uint, bool, error)
+}
+
+//TxStore is a Store whose operations are carried out within a single transaction
+type TxStore interface {
+	Store
+	//Commit commits the transaction
+	Commit() error
+	//Rollback abandons the transaction
+	Rollback() error
+}
+
+//LedgerTotal is the sum of the journal entries for a ledger
+type LedgerTotal struct {
+	Nominal Nominal
+	AcDr    int64
+	AcCr    int64
+}
+
+//Ledger is the stored form of an Account
+type Ledger struct {
+	PrntId  uint64
+	Id      uint64
+	Nominal Nominal
+	Name    string
+	Tpe     string
+	AcDr    int64
+	AcCr    int64
+	ChartId uint64
+}
+
+//Ledgers is a set of Ledger
+type Ledgers []Ledger
+
+//withTotals returns a copy of the ledgers with their values set from totals, rolled up through
+//the parent ledgers in the same way as journal entries are when they are written
+func (l Ledgers) withTotals(totals []LedgerTotal) Ledgers {
+	ledgers := make(Ledgers, len(l))
+	ids := make(map[uint64]int, len(l))
+	nominals := make(map[Nominal]int, len(l))
+	for i, ledger := range l {
+		ledger.AcDr, ledger.AcCr = 0, 0
+		ledgers[i] = ledger
+		ids[ledger.Id] = i
+		nominals[ledger.Nominal] = i
+	}
+	for _, total := range totals {
+		i, ok := nominals[total.Nominal]
+		for ok {
+			ledgers[i].AcDr += total.AcDr
+			ledgers[i].AcCr += total.AcCr
+			i, ok = ids[ledgers[i].PrntId]
+		}
+	}
+	return ledgers
+}
+
+//descendants appends the nominals of all the child ledgers below the ledger prntId to nominals
+func (l Ledgers) descendants(prntId uint64, nominals []Nominal) []Nominal {
+	for _, ledger := range l {
+		if ledger.PrntId == prntId && ledger.Id != prntId {
+			nominals = l.descendants(ledger.Id, append(nominals, ledger.Nominal))
+		}
+	}
+	return nominals
+}
+
+//storedEntry is the stored form of an Entry. acDr and acCr are in the chart currency
+type storedEntry struct {
+	nominal    Nominal
+	acDr       int64
+	acCr       int64
+	crcy       string
+	crcyAmount int64
+	rate       float64
+}
+
+//entryColumns are the sa_journal_entry columns, from the table with the given alias, that are scanned by storedEntry.dest.
+//They are not null, so that they can be read from an outer join, where a journal without entries has an empty nominal
+func entryColumns(alias string) string {
+	return fmt.Sprintf("coalesce(%[1]s.nominal, ''), coalesce(%[1]s.acDr, 0), coalesce(%[1]s.acCr, 0), coalesce(%[1]s.crcy, ''), coalesce(%[1]s.crcyAmount, 0), coalesce(%[1]s.rate, 0)", alias)
+}
+
+//newStoredEntry returns the stored form of entry
+func newStoredEntry(entry Entry) storedEntry {
+	e := storedEntry{
+		nominal:    *entry.Id(),
+		crcy:       entry.Crcy(),
+		crcyAmount: entry.CrcyAmount(),
+		rate:       entry.Rate(),
+	}
+	drAc := *NewAcType().Dr()
+	if *entry.Type()&drAc == drAc {
+		e.acDr = entry.Amount()
+	} else {
+		e.acCr = entry.Amount()
+	}
+	return e
+}
+
+//dest returns the scan destinations for the columns of entryColumns
+func (e *storedEntry) dest() []interface{} {
+	return []interface{}{&e.nominal, &e.acDr, &e.acCr, &e.crcy, &e.crcyAmount, &e.rate}
+}
+
+//entry returns the Entry for the stored values
+func (e storedEntry) entry() *Entry {
+	amount, tpe := e.acDr, *NewAcType().Dr()
+	if e.acDr == 0 {
+		amount, tpe = e.acCr, *NewAcType().Cr()
+	}
+	if e.crcy == "" {
+		return NewEntry(e.nominal, amount, tpe)
+	}
+	return NewCurrencyEntry(e.nominal, amount, tpe, e.crcy, e.crcyAmount, e.rate)
+}
+
+//nullTime returns nil for a zero time, so that it is stored as null
+func nullTime(dt time.Time) interface{} {
+	if dt.IsZero() {
+		return nil
+	}
+	return dt.UTC()
+}
+
+//rowScanner is a *sql.Row or *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+//scanChartInfo scans the columns of chartInfoColumns into a ChartInfo
+func scanChartInfo(row rowScanner) (*ChartInfo, error) {
+	info := &ChartInfo{}
+	var created, fiscalYearStart sql.NullTime
+	err := row.Scan(&info.Id, &info.Name, &info.Crcy, &info.Description, &created, &fiscalYearStart)
+	if err == sql.ErrNoRows {
+		return nil, ErrNoChartName
+	}
+	if err != nil {
+		return nil, err
+	}
+	if created.Valid {
+		info.Created = created.Time.UTC()
+	}
+	if fiscalYearStart.Valid {
+		info.FiscalYearStart = fiscalYearStart.Time.UTC()
+	}
+	return info, nil
+}
+
+//listCharts returns the name and metadata of every chart in sa_coa, ordered by id
+func listCharts(ctx context.Context, db dbtx) ([]ChartInfo, error) {
+	res, err := db.QueryContext(ctx, "select "+chartInfoColumns+" from sa_coa order by id")
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+	charts := make([]ChartInfo, 0)
+	for res.Next() {
+		info, err := scanChartInfo(res)
+		if err != nil {
+			return nil, err
+		}
+		charts = append(charts, *info)
+	}
+	return charts, res.Err()
+}
+
+//chartInfoColumns are the sa_coa columns scanned by scanChartInfo
+const chartInfoColumns = "id, name, coalesce(crcy, ''), description, created, fiscalYearStart"
+
+//nullCrcy returns nil for an empty currency, so that it is stored as null
+func nullCrcy(crcy string) interface{} {
+	if crcy == "" {
+		return nil
+	}
+	return crcy
+}
+
+//nullId returns nil for a zero id, so that it is stored as null
+func nullId(id uint64) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+//nullKey returns true for a keyed journal, else nil so that the key is stored as null
+func nullKey(keyed bool) interface{} {
+	if keyed {
+		return true
+	}
+	return nil
+}
+
+//inSavepoint returns f run within a savepoint. If f fails the statements that it ran are rolled back,
+//leaving the enclosing transaction usable, e.g. after a keyed journal write hits the unique index
+func inSavepoint(ctx context.Context, f func(tx dbtx) error) func(tx dbtx) error {
+	return func(tx dbtx) error {
+		if _, err := tx.ExecContext(ctx, "savepoint sa_write_journal"); err != nil {
+			return err
+		}
+		if err := f(tx); err != nil {
+			if _, rbErr := tx.ExecContext(ctx, "rollback to savepoint sa_write_journal"); rbErr != nil {
+				return rbErr
+			}
+			return err
+		}
+		_, err := tx.ExecContext(ctx, "release savepoint sa_write_journal")
+		return err
+	}
+}
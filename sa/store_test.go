@@ -0,0 +1,52 @@
+//go:build unit
+// +build unit
+
+package sa_test
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"github.com/chippyash/go-simple-accounts/sa"
+	"github.com/chippyash/go-simple-accounts/sa/sqlite"
+	"testing"
+)
+
+func TestStores_ReverseTransaction(t *testing.T) {
+	for name, accountant := range storeTestAccountants(t) {
+		assertReverseTransaction(t, accountant, name)
+	}
+}
+
+func TestStores_FetchChartAsAt(t *testing.T) {
+	for name, accountant := range storeTestAccountants(t) {
+		assertChartAsAt(t, accountant, name)
+	}
+}
+
+func TestStores_AccountStatement(t *testing.T) {
+	for name, accountant := range storeTestAccountants(t) {
+		assertAccountStatement(t, accountant, name)
+	}
+}
+
+func TestStores_NoteWithWildcards(t *testing.T) {
+	for name, accountant := range storeTestAccountants(t) {
+		assertNoteWithWildcards(t, accountant, name)
+	}
+}
+
+func TestStores_KeyedJournalInTx(t *testing.T) {
+	setupSqliteStoreTest(t)
+	for name, store := range map[string]sa.Store{
+		"memory": sa.NewMemoryStore(),
+		"sqlite": sqlite.NewStore(sqliteDb),
+	} {
+		assertKeyedJournalInTx(t, store, name)
+	}
+}
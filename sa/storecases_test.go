@@ -0,0 +1,265 @@
+//go:build unit || integration
+// +build unit integration
+
+package sa_test
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+//The cases in this file are run against each store, by the unit tests in store_test.go
+//and the integration tests in accountant_test.go and postgresstore_test.go.
+//The tests that use storeTestAccountants are run against the memory and SQLite stores by the unit tests,
+//and against the MySQL and PostgreSQL stores by the integration tests
+
+import (
+	"context"
+	"github.com/chippyash/go-simple-accounts/sa"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+//testAccountants return an accountant, with the Test chart in a new database, for each store under test.
+//The unit tests add the stores that can be tested without a database server, and the integration tests add the others
+var testAccountants = make(map[string]func(t *testing.T) *sa.Accountant)
+
+//storeTestAccountants returns an accountant, with the Test chart in a new database, for each store under test
+func storeTestAccountants(t *testing.T) map[string]*sa.Accountant {
+	accountants := make(map[string]*sa.Accountant, len(testAccountants))
+	for name, setup := range testAccountants {
+		accountants[name] = setup(t)
+	}
+	return accountants
+}
+
+//assertReverseTransaction reverses a journal, voiding it, and checks that it cannot be reversed again
+func assertReverseTransaction(t *testing.T, accountant *sa.Accountant, name string) {
+	dt, _ := time.Parse(time.RFC3339, "2020-08-05T14:36:00+01:00")
+	txn := sa.NewSimpleTransactionBuilder(0, "1210", "4100", 100).Build()
+	jrnId, _ := accountant.WriteTransactionWithDate(txn, dt)
+
+	revId, err := accountant.ReverseTransaction(jrnId, dt.Add(time.Hour), "wrong income account")
+	assert.NoError(t, err, name)
+
+	original, err := accountant.FetchTransaction(jrnId)
+	assert.NoError(t, err, name)
+	assert.True(t, original.IsVoid(), name)
+	assert.Equal(t, revId, original.ReversedBy(), name)
+	reversal, err := accountant.FetchTransaction(revId)
+	assert.NoError(t, err, name)
+	assert.False(t, reversal.IsVoid(), name)
+	assert.Equal(t, jrnId, reversal.ReversalOf(), name)
+	assert.Equal(t, "wrong income account", reversal.Note(), name)
+	assert.Equal(t, "2020-08-05T14:36:00Z", reversal.Date().Format(time.RFC3339), name)
+	assert.Equal(t, "4100", reversal.GetDrAc()[0].String(), name)
+	assert.Equal(t, "1210", reversal.GetCrAc()[0].String(), name)
+
+	journals, err := accountant.FetchAccountJournals("1210")
+	assert.NoError(t, err, name)
+	assert.Equal(t, 2, len(journals), name)
+	assert.True(t, journals[0].IsVoid(), name)
+	assert.Equal(t, jrnId, journals[1].ReversalOf(), name)
+
+	chart, _ := accountant.FetchChart()
+	assert.Equal(t, int64(100), chart.GetAccount("1210").Dr(), name)
+	assert.Equal(t, int64(100), chart.GetAccount("1210").Cr(), name)
+
+	_, err = accountant.ReverseTransaction(jrnId, dt, "")
+	assert.ErrorIs(t, err, sa.ErrJournalVoid, name)
+	_, err = accountant.ReverseTransaction(revId, dt, "")
+	assert.ErrorIs(t, err, sa.ErrReversalJournal, name)
+	_, err = accountant.ReverseTransaction(revId+1, dt, "")
+	assert.ErrorIs(t, err, sa.ErrJournalNotFound, name)
+}
+
+//assertChartAsAt checks the account values of a chart fetched as at a date, and the balance of an account as at a date
+func assertChartAsAt(t *testing.T, accountant *sa.Accountant, name string) {
+	for _, posting := range []struct {
+		date   string
+		dr, cr string
+		amount int64
+	}{
+		{"2020-07-31T12:00:00Z", "1210", "4100", 100},
+		{"2020-08-31T23:00:00+01:00", "1210", "4100", 50},
+		{"2020-08-31T23:30:00Z", "6120", "1210", 20},
+		{"2020-09-15T12:00:00Z", "1210", "4100", 1000},
+	} {
+		dt, _ := time.Parse(time.RFC3339, posting.date)
+		txn := sa.NewSimpleTransactionBuilder(0, sa.Nominal(posting.dr), sa.Nominal(posting.cr), posting.amount).Build()
+		_, err := accountant.WriteTransactionWithDate(txn, dt)
+		assert.NoError(t, err, name)
+	}
+
+	monthEnd, _ := time.Parse(time.RFC3339, "2020-08-31T23:59:59Z")
+	chart, err := accountant.FetchChartAsAt(monthEnd)
+	assert.NoError(t, err, name)
+	assert.Equal(t, int64(150), chart.GetAccount("1210").Dr(), name)
+	assert.Equal(t, int64(20), chart.GetAccount("1210").Cr(), name)
+	assert.Equal(t, int64(150), chart.GetAccount("1000").Dr(), name)
+	assert.Equal(t, int64(170), chart.GetAccount("0000").Dr(), name)
+	assert.Equal(t, int64(170), chart.GetAccount("0000").Cr(), name)
+
+	balance, err := accountant.BalanceAsAt("1210", monthEnd)
+	assert.NoError(t, err, name)
+	assert.Equal(t, int64(130), balance, name)
+	balance, err = accountant.BalanceAsAt("1210", monthEnd.AddDate(0, -1, 0))
+	assert.NoError(t, err, name)
+	assert.Equal(t, int64(100), balance, name)
+	balance, err = accountant.BalanceAsAt("1210", monthEnd.AddDate(-1, 0, 0))
+	assert.NoError(t, err, name)
+	assert.Equal(t, int64(0), balance, name)
+	_, err = accountant.BalanceAsAt("9999", monthEnd)
+	assert.ErrorIs(t, err, sa.ErrBadNominal, name)
+
+	//live values are unchanged
+	chart, _ = accountant.FetchChart()
+	assert.Equal(t, int64(1150), chart.GetAccount("1210").Dr(), name)
+}
+
+//assertAccountStatement checks the statements of accounts with journals written out of date order
+func assertAccountStatement(t *testing.T, accountant *sa.Accountant, name string) {
+	//written out of date order
+	for _, posting := range []struct {
+		date   string
+		dr, cr string
+		amount int64
+	}{
+		{"2020-08-10T12:00:00Z", "1220", "4100", 500},
+		{"2020-07-10T12:00:00Z", "1210", "4100", 1000},
+		{"2020-08-05T12:00:00Z", "6121", "1210", 100},
+		{"2020-09-01T12:00:00Z", "1210", "1220", 200},
+	} {
+		dt, _ := time.Parse(time.RFC3339, posting.date)
+		txn := sa.NewSimpleTransactionBuilder(0, sa.Nominal(posting.dr), sa.Nominal(posting.cr), posting.amount).Build()
+		_, err := accountant.WriteTransactionWithDate(txn, dt)
+		assert.NoError(t, err, name)
+	}
+	from, _ := time.Parse(time.RFC3339, "2020-08-01T00:00:00Z")
+	to, _ := time.Parse(time.RFC3339, "2020-08-31T23:59:59Z")
+
+	statement, err := accountant.AccountStatement("1210", from, to)
+	assert.NoError(t, err, name)
+	assert.Equal(t, "Current Accounts", statement.Name, name)
+	assert.Equal(t, int64(1000), statement.OpeningBalance, name)
+	assert.Equal(t, 1, len(statement.Lines), name)
+	assert.Equal(t, uint64(3), statement.Lines[0].JrnId, name)
+	assert.Equal(t, int64(0), statement.Lines[0].Dr, name)
+	assert.Equal(t, int64(100), statement.Lines[0].Cr, name)
+	assert.Equal(t, int64(900), statement.Lines[0].Balance, name)
+	assert.Equal(t, int64(900), statement.ClosingBalance, name)
+
+	//all journals, in date order
+	statement, err = accountant.AccountStatement("1210", time.Time{}, time.Time{})
+	assert.NoError(t, err, name)
+	assert.Equal(t, int64(0), statement.OpeningBalance, name)
+	assert.Equal(t, 3, len(statement.Lines), name)
+	assert.Equal(t, uint64(2), statement.Lines[0].JrnId, name)
+	assert.Equal(t, int64(1000), statement.Lines[0].Balance, name)
+	assert.Equal(t, int64(1100), statement.ClosingBalance, name)
+
+	//a credit account balance increases with credits
+	statement, err = accountant.AccountStatement("4100", time.Time{}, time.Time{})
+	assert.NoError(t, err, name)
+	assert.Equal(t, int64(1000), statement.Lines[0].Balance, name)
+	assert.Equal(t, int64(1500), statement.ClosingBalance, name)
+
+	//a parent account including its children
+	statement, err = accountant.AccountStatementWithChildren("1200", from, time.Time{})
+	assert.NoError(t, err, name)
+	assert.Equal(t, int64(1000), statement.OpeningBalance, name)
+	assert.Equal(t, 4, len(statement.Lines), name)
+	assert.Equal(t, sa.Nominal("1210"), statement.Lines[0].Nominal, name)
+	assert.Equal(t, int64(900), statement.Lines[0].Balance, name)
+	assert.Equal(t, sa.Nominal("1220"), statement.Lines[1].Nominal, name)
+	assert.Equal(t, int64(1400), statement.Lines[1].Balance, name)
+	assert.Equal(t, int64(1400), statement.ClosingBalance, name)
+
+	//without its children a parent account has no journals
+	statement, err = accountant.AccountStatement("1200", from, time.Time{})
+	assert.NoError(t, err, name)
+	assert.Equal(t, 0, len(statement.Lines), name)
+	assert.Equal(t, int64(0), statement.ClosingBalance, name)
+
+	_, err = accountant.AccountStatement("9999", from, to)
+	assert.ErrorIs(t, err, sa.ErrBadNominal, name)
+}
+
+//assertKeyedJournalInTx writes the same src and ref twice within one transaction. The duplicate write is rejected
+//without leaving its journal or ledger values in the transaction, which can still be used and committed
+func assertKeyedJournalInTx(t *testing.T, store sa.Store, name string) {
+	ctx := context.Background()
+	dt, _ := time.Parse(time.RFC3339, "2020-07-31T12:00:00Z")
+	def, err := sa.NewChartDefinition("../tests/_data/personal.xml")
+	assert.NoError(t, err, name)
+	accountant := sa.NewAccountant(store, 0, "GBP")
+	chartId, err := accountant.CreateChart("Keyed", "GBP", def)
+	assert.NoError(t, err, name)
+
+	var jrnIds [2]uint64
+	err = accountant.InTx(func(tx *sa.AccountantTx) error {
+		for i := range jrnIds {
+			txn := sa.NewSimpleTransactionBuilder(0, "1210", "4100", 100).WithSource("INV").WithReference(42).Build()
+			jrnId, err := tx.WriteTransactionOnce(txn, dt)
+			if err != nil {
+				return err
+			}
+			jrnIds[i] = jrnId
+		}
+		return nil
+	})
+	assert.NoError(t, err, name)
+	assert.Equal(t, jrnIds[0], jrnIds[1], name)
+
+	txStore, err := store.Begin(ctx)
+	if !assert.NoError(t, err, name) {
+		return
+	}
+	txn := sa.NewSimpleTransactionBuilder(0, "1210", "4100", 100).WithSource("INV").WithReference(43).Build()
+	jrnId, err := txStore.WriteKeyedJournal(ctx, chartId, txn, dt)
+	assert.NoError(t, err, name)
+	_, err = txStore.WriteKeyedJournal(ctx, chartId, txn, dt)
+	assert.ErrorIs(t, err, sa.ErrDuplicateJournal, name)
+	journals, err := txStore.QueryJournals(ctx, chartId, sa.JournalFilter{Src: "INV", Ref: 43})
+	assert.NoError(t, err, name)
+	if assert.Equal(t, 1, len(journals), name) {
+		assert.Equal(t, jrnId, journals[0].Id(), name)
+	}
+	assert.NoError(t, txStore.Commit(), name)
+
+	chart, err := accountant.FetchChart()
+	assert.NoError(t, err, name)
+	assert.Equal(t, int64(200), chart.GetAccount("1210").Dr(), name)
+}
+
+//assertNoteWithWildcards checks that the like wildcards, and the escape character, in a note filter match themselves
+func assertNoteWithWildcards(t *testing.T, accountant *sa.Accountant, name string) {
+	notes := []string{"50% deposit", "500 deposit", "ref a_b", "ref axb", "ref a!b", "ref a!!b"}
+	for _, note := range notes {
+		_, err := accountant.WriteTransaction(sa.NewSimpleTransactionBuilder(0, "1210", "4100", 100).WithNote(note).Build())
+		assert.NoError(t, err, name)
+	}
+	for text, expected := range map[string][]string{
+		"50%":      {"50% deposit"},
+		"a_b":      {"ref a_b"},
+		"a!b":      {"ref a!b"},
+		"deposit":  {"50% deposit", "500 deposit"},
+		"%":        {"50% deposit"},
+		"A_B":      {"ref a_b"},
+		"not here": nil,
+	} {
+		page, err := accountant.QueryJournals(sa.NewJournalQuery().WithNoteContaining(text).OrderBy(sa.JournalOrderId))
+		if !assert.NoError(t, err, "%s: %s", name, text) {
+			continue
+		}
+		var found []string
+		for _, jrn := range page.Journals {
+			found = append(found, jrn.Note())
+		}
+		assert.Equal(t, expected, found, "%s: %s", name, text)
+	}
+}
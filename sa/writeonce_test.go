@@ -0,0 +1,149 @@
+//go:build unit
+// +build unit
+
+package sa_test
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"context"
+	"errors"
+	"github.com/chippyash/go-simple-accounts/sa"
+	"github.com/chippyash/go-simple-accounts/sa/sqlite"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAccountant_WriteTransactionOnceIsIdempotent(t *testing.T) {
+	dt, _ := time.Parse(time.RFC3339, "2020-07-31T12:00:00Z")
+	for name, accountant := range storeTestAccountants(t) {
+		txn := sa.NewSimpleTransactionBuilder(0, "1210", "4100", 100).WithSource("INV").WithReference(42).Build()
+		jrnId, err := accountant.WriteTransactionOnce(txn, dt)
+		assert.NoError(t, err, name)
+
+		//a retry, with a new note and date, returns the original journal
+		retry := sa.NewSimpleTransactionBuilder(0, "1210", "4100", 100).
+			WithSource("INV").
+			WithReference(42).
+			WithNote("retried").
+			Build()
+		retryId, err := accountant.WriteTransactionOnce(retry, dt.AddDate(0, 0, 1))
+		assert.NoError(t, err, name)
+		assert.Equal(t, jrnId, retryId, name)
+
+		page, _ := accountant.QueryJournals(sa.NewJournalQuery().WithSource("INV"))
+		assert.Equal(t, 1, len(page.Journals), name)
+		chart, _ := accountant.FetchChart()
+		assert.Equal(t, int64(100), chart.GetAccount("1210").Dr(), name)
+
+		//the same ref from another source is a different journal
+		other := sa.NewSimpleTransactionBuilder(0, "1210", "4100", 100).WithSource("BILL").WithReference(42).Build()
+		otherId, err := accountant.WriteTransactionOnce(other, dt)
+		assert.NoError(t, err, name)
+		assert.NotEqual(t, jrnId, otherId, name)
+	}
+}
+
+func TestAccountant_WriteTransactionOnceConflict(t *testing.T) {
+	dt, _ := time.Parse(time.RFC3339, "2020-07-31T12:00:00Z")
+	for name, accountant := range storeTestAccountants(t) {
+		txn := sa.NewSimpleTransactionBuilder(0, "1210", "4100", 100).WithSource("INV").WithReference(42).Build()
+		jrnId, _ := accountant.WriteTransactionOnce(txn, dt)
+
+		for test, entries := range map[string][2]sa.Nominal{
+			"amount":   {"1210", "4100"},
+			"accounts": {"1220", "4100"},
+			"sides":    {"4100", "1210"},
+		} {
+			amount := int64(100)
+			if test == "amount" {
+				amount = 200
+			}
+			changed := sa.NewSimpleTransactionBuilder(0, entries[0], entries[1], amount).WithSource("INV").WithReference(42).Build()
+			_, err := accountant.WriteTransactionOnce(changed, dt)
+			assert.ErrorIs(t, err, sa.ErrJournalConflict, "%s: %s", name, test)
+			var conflict *sa.JournalConflictError
+			if assert.True(t, errors.As(err, &conflict), "%s: %s", name, test) {
+				assert.Equal(t, "INV", conflict.Src, name)
+				assert.Equal(t, uint64(42), conflict.Ref, name)
+				assert.Equal(t, jrnId, conflict.JrnId, name)
+			}
+		}
+
+		_, err := accountant.WriteTransactionOnce(sa.NewSimpleTransactionBuilder(0, "1210", "4100", 100).WithReference(1).Build(), dt)
+		assert.ErrorIs(t, err, sa.ErrNoJournalKey, name)
+		_, err = accountant.WriteTransactionOnce(sa.NewSimpleTransactionBuilder(0, "1210", "4100", 100).WithSource("INV").Build(), dt)
+		assert.ErrorIs(t, err, sa.ErrNoJournalKey, name)
+	}
+}
+
+func TestAccountant_WriteTransactionOnceFindsExistingJournal(t *testing.T) {
+	dt, _ := time.Parse(time.RFC3339, "2020-07-31T12:00:00Z")
+	for name, accountant := range storeTestAccountants(t) {
+		//journals written without a key can share a src and ref
+		txn := sa.NewSimpleTransactionBuilder(0, "1210", "4100", 100).WithSource("INV").WithReference(7).Build()
+		firstId, err := accountant.WriteTransactionWithDate(txn, dt)
+		assert.NoError(t, err, name)
+		_, err = accountant.WriteTransactionWithDate(txn, dt)
+		assert.NoError(t, err, name)
+
+		jrnId, err := accountant.WriteTransactionOnce(txn, dt)
+		assert.NoError(t, err, name)
+		assert.Equal(t, firstId, jrnId, name)
+	}
+}
+
+func TestAccountant_WriteTransactionOnceConcurrently(t *testing.T) {
+	setupMemoryStoreTest(t)
+	dt, _ := time.Parse(time.RFC3339, "2020-07-31T12:00:00Z")
+	ids := make([]uint64, 20)
+	var wg sync.WaitGroup
+	for i := range ids {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			txn := sa.NewSimpleTransactionBuilder(0, "1210", "4100", 100).WithSource("INV").WithReference(42).Build()
+			jrnId, err := memAccountant.WriteTransactionOnce(txn, dt)
+			assert.NoError(t, err)
+			ids[i] = jrnId
+		}(i)
+	}
+	wg.Wait()
+	for _, jrnId := range ids {
+		assert.Equal(t, ids[0], jrnId)
+	}
+	chart, _ := memAccountant.FetchChart()
+	assert.Equal(t, int64(100), chart.GetAccount("1210").Dr())
+}
+
+func TestStore_WriteKeyedJournalRejectsDuplicateKey(t *testing.T) {
+	setupSqliteStoreTest(t)
+	dt, _ := time.Parse(time.RFC3339, "2020-07-31T12:00:00Z")
+	memStore := sa.NewMemoryStore()
+	memChartId, _ := memStore.CreateChart(context.Background(), "Test")
+	for name, store := range map[string]sa.Store{
+		"memory": memStore,
+		"sqlite": sqlite.NewStore(sqliteDb),
+	} {
+		chartId := uint64(1)
+		if name == "memory" {
+			chartId = memChartId
+		}
+		txn := sa.NewSplitTransactionBuilder(0).WithSource("INV").WithReference(42).Build()
+		_, err := store.WriteKeyedJournal(context.Background(), chartId, txn, dt)
+		assert.NoError(t, err, name)
+		_, err = store.WriteKeyedJournal(context.Background(), chartId, txn, dt)
+		assert.ErrorIs(t, err, sa.ErrDuplicateJournal, name)
+		//a journal without a key is not constrained
+		_, err = store.WriteJournal(context.Background(), chartId, txn, dt)
+		assert.NoError(t, err, name)
+	}
+}
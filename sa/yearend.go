@@ -0,0 +1,153 @@
+package sa
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//YearEndSource is the src of year end closing journals. Their ref is the year end date as yyyymmdd
+const YearEndSource = "YREND"
+
+//CloseYear closes the year that ends at periodEnd, which is the last moment of the year.
+//A closing journal, dated periodEnd, zeroes the balance of every INCOME and EXPENSE account
+//into the retainedEarnings EQUITY account, and then every open period that starts on or before periodEnd is closed.
+//If there is nothing to close, the closing journal has no entries, and only records the close.
+//The closing journal is written even if the periods of the year are already closed, but ErrPeriodLocked is returned
+//if a period of the year, which is the year up to periodEnd, is locked.
+//The ref of the closing journal is the date of periodEnd in its own location.
+//The id of the closing journal is returned. ErrYearClosed is returned if the year has already been closed
+func (a *Accountant) CloseYear(periodEnd time.Time, retainedEarnings Nominal) (uint64, error) {
+	return a.CloseYearContext(context.Background(), periodEnd, retainedEarnings)
+}
+
+//CloseYearContext closes the year that ends at periodEnd into the retainedEarnings account
+func (a *Accountant) CloseYearContext(ctx context.Context, periodEnd time.Time, retainedEarnings Nominal) (uint64, error) {
+	if a.chartId == 0 {
+		return 0, ErrNoChartId
+	}
+	if err := a.checkSchema(ctx); err != nil {
+		return 0, err
+	}
+	if _, ok := a.store.(TxStore); ok {
+		return a.closeYear(ctx, periodEnd, retainedEarnings)
+	}
+	var jrnId uint64
+	err := a.InTxContext(ctx, func(tx *AccountantTx) error {
+		var err error
+		jrnId, err = tx.closeYear(ctx, periodEnd, retainedEarnings)
+		return err
+	})
+	return jrnId, err
+}
+
+func (a *Accountant) closeYear(ctx context.Context, periodEnd time.Time, retainedEarnings Nominal) (uint64, error) {
+	ledgers, err := a.store.FetchLedgers(ctx, a.chartId)
+	if err != nil {
+		return 0, err
+	}
+	types := make(map[Nominal]string, len(ledgers))
+	for _, ledger := range ledgers {
+		types[ledger.Nominal] = ledger.Tpe
+	}
+	if tpe, ok := types[retainedEarnings]; !ok {
+		return 0, ErrBadNominal
+	} else if tpe != "EQUITY" {
+		return 0, ErrNotEquityAccount
+	}
+
+	//the year is closed if there is a closing journal for the date, or at the same moment given in another location
+	ref := dateRef(periodEnd)
+	for _, filter := range []JournalFilter{
+		{Src: YearEndSource, Ref: ref, Limit: 1},
+		{Src: YearEndSource, From: periodEnd, To: periodEnd, Limit: 1},
+	} {
+		closed, err := a.store.QueryJournals(ctx, a.chartId, filter)
+		if err != nil {
+			return 0, err
+		}
+		if len(closed) > 0 {
+			return 0, ErrYearClosed
+		}
+	}
+	periods, err := a.store.FetchPeriods(ctx, a.chartId)
+	if err != nil {
+		return 0, err
+	}
+	yearStart := periodEnd.AddDate(-1, 0, 0)
+	for _, p := range periods {
+		if p.Status == PeriodLocked && p.Start.After(yearStart) && !p.Start.After(periodEnd) {
+			return 0, fmt.Errorf("%w: %s", ErrPeriodLocked, p.Name)
+		}
+	}
+
+	totals, err := a.store.FetchLedgerTotals(ctx, a.chartId, time.Time{}, periodEnd)
+	if err != nil {
+		return 0, err
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		return totals[i].Nominal < totals[j].Nominal
+	})
+	drAc := *NewAcType().Dr()
+	crAc := *NewAcType().Cr()
+	txn := NewSplitTransactionBuilder(0).
+		WithDate(periodEnd).
+		WithSource(YearEndSource).
+		WithReference(ref).
+		WithNote(fmt.Sprintf("Year end close to %s", periodEnd.Format("2006-01-02")))
+	var net int64
+	for _, total := range totals {
+		if tpe := types[total.Nominal]; tpe != "INCOME" && tpe != "EXPENSE" {
+			continue
+		}
+		balance := total.AcDr - total.AcCr
+		switch {
+		case balance > 0:
+			txn = txn.WithEntry(*NewEntry(total.Nominal, balance, crAc))
+		case balance < 0:
+			txn = txn.WithEntry(*NewEntry(total.Nominal, -balance, drAc))
+		default:
+			continue
+		}
+		net += balance
+	}
+
+	switch {
+	case net > 0:
+		txn = txn.WithEntry(*NewEntry(retainedEarnings, net, drAc))
+	case net < 0:
+		txn = txn.WithEntry(*NewEntry(retainedEarnings, -net, crAc))
+	}
+	jrnId, err := a.store.WriteKeyedJournal(ctx, a.chartId, txn.Build(), periodEnd)
+	if err == ErrDuplicateJournal {
+		return 0, ErrYearClosed
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	for _, p := range periods {
+		if p.Status == PeriodOpen && !p.Start.After(periodEnd) {
+			if err = a.store.SetPeriodStatus(ctx, a.chartId, p.Id, PeriodClosed); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return jrnId, nil
+}
+
+//dateRef returns the journal ref for a date, as yyyymmdd in the location of dt
+func dateRef(dt time.Time) uint64 {
+	ref, _ := strconv.ParseUint(dt.Format("20060102"), 10, 64)
+	return ref
+}
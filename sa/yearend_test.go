@@ -0,0 +1,198 @@
+//go:build unit || integration
+// +build unit integration
+
+package sa_test
+
+/**
+ * Simple Double Entry Accounting V3 for Go
+
+ * @author Ashley Kitson
+ * @copyright Ashley Kitson, 2022, UK
+ * @license BSD-3-Clause See LICENSE.md
+ */
+
+import (
+	"github.com/chippyash/go-simple-accounts/sa"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestAccountant_CloseYear(t *testing.T) {
+	start, _ := time.Parse(time.RFC3339, "2020-04-01T00:00:00Z")
+	yearEnd, _ := time.Parse(time.RFC3339, "2021-03-31T23:59:59Z")
+	equity := sa.Nominal("3000")
+	for name, accountant := range storeTestAccountants(t) {
+		assert.NoError(t, accountant.AddAccount("3200", sa.NewAcType().Equity(), "Retained Earnings", &equity), name)
+		periods, err := accountant.DefineFiscalYear("FY2020", start, sa.PeriodsMonthly)
+		assert.NoError(t, err, name)
+		for _, posting := range []struct {
+			date   string
+			dr, cr sa.Nominal
+			amount int64
+		}{
+			{"2020-05-01T12:00:00Z", "1210", "4100", 1000},
+			{"2020-06-01T12:00:00Z", "1210", "4200", 200},
+			{"2020-07-01T12:00:00Z", "6121", "1210", 300},
+			{"2020-08-01T12:00:00Z", "6400", "1210", 150},
+			{"2021-04-15T12:00:00Z", "1210", "4100", 500},
+		} {
+			dt, _ := time.Parse(time.RFC3339, posting.date)
+			_, err = accountant.WriteTransactionWithDate(sa.NewSimpleTransactionBuilder(0, posting.dr, posting.cr, posting.amount).Build(), dt)
+			assert.NoError(t, err, name)
+		}
+
+		jrnId, err := accountant.CloseYear(yearEnd, "3200")
+		assert.NoError(t, err, name)
+		jrn, err := accountant.FetchTransaction(jrnId)
+		assert.NoError(t, err, name)
+		assert.Equal(t, sa.YearEndSource, jrn.Src(), name)
+		assert.Equal(t, uint64(20210331), jrn.Ref(), name)
+		assert.Equal(t, 5, len(jrn.Entries()), name)
+		assert.True(t, jrn.CheckBalance(), name)
+
+		for nominal, expected := range map[sa.Nominal]int64{"4100": 0, "4200": 0, "6121": 0, "6400": 0, "3200": 750, "1210": 750} {
+			balance, err := accountant.BalanceAsAt(nominal, yearEnd)
+			assert.NoError(t, err, name)
+			assert.Equal(t, expected, balance, "%s: %s", name, nominal)
+		}
+		//journals after the year end are not closed
+		balance, _ := accountant.BalanceAsAt("4100", yearEnd.AddDate(0, 1, 0))
+		assert.Equal(t, int64(500), balance, name)
+
+		fetched, _ := accountant.Periods()
+		for i, p := range fetched {
+			assert.Equal(t, periods[i].Id, p.Id, name)
+			assert.Equal(t, sa.PeriodClosed, p.Status, name)
+		}
+
+		_, err = accountant.CloseYear(yearEnd, "3200")
+		assert.ErrorIs(t, err, sa.ErrYearClosed, name)
+		_, err = accountant.CloseYear(yearEnd.AddDate(1, 0, 0), "9999")
+		assert.ErrorIs(t, err, sa.ErrBadNominal, name)
+		_, err = accountant.CloseYear(yearEnd.AddDate(1, 0, 0), "4100")
+		assert.ErrorIs(t, err, sa.ErrNotEquityAccount, name)
+	}
+}
+
+func TestAccountant_CloseYearWithoutPeriods(t *testing.T) {
+	yearEnd, _ := time.Parse(time.RFC3339, "2020-12-31T23:59:59Z")
+	for name, accountant := range storeTestAccountants(t) {
+		dt, _ := time.Parse(time.RFC3339, "2020-05-01T12:00:00Z")
+		_, err := accountant.WriteTransactionWithDate(sa.NewSimpleTransactionBuilder(0, "6121", "1210", 300).Build(), dt)
+		assert.NoError(t, err, name)
+
+		jrnId, err := accountant.CloseYear(yearEnd, "3100")
+		assert.NoError(t, err, name)
+		assert.NotEqual(t, uint64(0), jrnId, name)
+		balance, _ := accountant.BalanceAsAt("3100", yearEnd)
+		assert.Equal(t, int64(-300), balance, name)
+
+		//the same year is detected from its closing journal
+		_, err = accountant.CloseYear(yearEnd, "3100")
+		assert.ErrorIs(t, err, sa.ErrYearClosed, name)
+
+		//a year with nothing to close is recorded by a closing journal without entries
+		jrnId, err = accountant.CloseYear(yearEnd.AddDate(1, 0, 0), "3100")
+		assert.NoError(t, err, name)
+		jrn, err := accountant.FetchTransaction(jrnId)
+		assert.NoError(t, err, name)
+		assert.Equal(t, sa.YearEndSource, jrn.Src(), name)
+		assert.Equal(t, 0, len(jrn.Entries()), name)
+		it, err := accountant.Journals(sa.NewJournalQuery().WithSource(sa.YearEndSource).OrderBy(sa.JournalOrderId))
+		assert.NoError(t, err, name)
+		closes := 0
+		for it.Next() {
+			closes++
+		}
+		assert.NoError(t, it.Err(), name)
+		assert.NoError(t, it.Close(), name)
+		assert.Equal(t, 2, closes, name)
+		balance, _ = accountant.BalanceAsAt("3100", yearEnd.AddDate(1, 0, 0))
+		assert.Equal(t, int64(-300), balance, name)
+		_, err = accountant.CloseYear(yearEnd.AddDate(1, 0, 0), "3100")
+		assert.ErrorIs(t, err, sa.ErrYearClosed, name)
+
+		//closing inside a transaction
+		err = accountant.InTx(func(tx *sa.AccountantTx) error {
+			_, err := tx.WriteTransactionWithDate(sa.NewSimpleTransactionBuilder(0, "1210", "4100", 100).Build(), dt.AddDate(2, 0, 0))
+			if err != nil {
+				return err
+			}
+			jrnId, err = tx.CloseYear(yearEnd.AddDate(2, 0, 0), "3100")
+			return err
+		})
+		assert.NoError(t, err, name)
+		assert.NotEqual(t, uint64(0), jrnId, name)
+	}
+}
+
+func TestAccountant_CloseYearWithClosedPeriods(t *testing.T) {
+	start, _ := time.Parse(time.RFC3339, "2020-01-01T00:00:00Z")
+	yearEnd, _ := time.Parse(time.RFC3339, "2020-12-31T23:59:59Z")
+	for name, accountant := range storeTestAccountants(t) {
+		periods, err := accountant.DefineFiscalYear("FY2020", start, sa.PeriodsMonthly)
+		assert.NoError(t, err, name)
+		dt, _ := time.Parse(time.RFC3339, "2020-05-01T12:00:00Z")
+		_, err = accountant.WriteTransactionWithDate(sa.NewSimpleTransactionBuilder(0, "6121", "1210", 300).Build(), dt)
+		assert.NoError(t, err, name)
+
+		//the month end routine has closed the final period
+		assert.NoError(t, accountant.ClosePeriod(periods[11].Id), name)
+
+		jrnId, err := accountant.CloseYear(yearEnd, "3100")
+		assert.NoError(t, err, name)
+		assert.NotEqual(t, uint64(0), jrnId, name)
+		balance, _ := accountant.BalanceAsAt("3100", yearEnd)
+		assert.Equal(t, int64(-300), balance, name)
+		fetched, _ := accountant.Periods()
+		for _, p := range fetched {
+			assert.Equal(t, sa.PeriodClosed, p.Status, name)
+		}
+	}
+}
+
+func TestAccountant_CloseYearWithLockedPeriod(t *testing.T) {
+	start, _ := time.Parse(time.RFC3339, "2020-01-01T00:00:00Z")
+	yearEnd, _ := time.Parse(time.RFC3339, "2020-12-31T23:59:59Z")
+	for name, accountant := range storeTestAccountants(t) {
+		periods, err := accountant.DefineFiscalYear("FY2020", start, sa.PeriodsMonthly)
+		assert.NoError(t, err, name)
+		dt, _ := time.Parse(time.RFC3339, "2020-05-01T12:00:00Z")
+		_, err = accountant.WriteTransactionWithDate(sa.NewSimpleTransactionBuilder(0, "6121", "1210", 300).Build(), dt)
+		assert.NoError(t, err, name)
+		assert.NoError(t, accountant.LockPeriod(periods[10].Id), name)
+
+		_, err = accountant.CloseYear(yearEnd, "3100")
+		assert.ErrorIs(t, err, sa.ErrPeriodLocked, name)
+		balance, _ := accountant.BalanceAsAt("3100", yearEnd)
+		assert.Equal(t, int64(0), balance, name)
+		fetched, _ := accountant.Periods()
+		assert.Equal(t, sa.PeriodOpen, fetched[0].Status, name)
+	}
+}
+
+func TestAccountant_CloseYearInAnotherLocation(t *testing.T) {
+	yearEnd, _ := time.Parse(time.RFC3339, "2022-01-01T00:30:00+01:00")
+	for name, accountant := range storeTestAccountants(t) {
+		jrnId, err := accountant.CloseYear(yearEnd, "3100")
+		assert.NoError(t, err, name)
+		jrn, err := accountant.FetchTransaction(jrnId)
+		assert.NoError(t, err, name)
+		assert.Equal(t, uint64(20220101), jrn.Ref(), name)
+
+		//the same moment in UTC is the same close
+		_, err = accountant.CloseYear(yearEnd.UTC(), "3100")
+		assert.ErrorIs(t, err, sa.ErrYearClosed, name)
+		//as is the same date at another moment
+		_, err = accountant.CloseYear(yearEnd.Add(time.Hour), "3100")
+		assert.ErrorIs(t, err, sa.ErrYearClosed, name)
+	}
+}
+
+func TestBuiltInSourcesFitJournalSrc(t *testing.T) {
+	//sa_journal.src is varchar(6)
+	for _, src := range []string{sa.YearEndSource, sa.OpeningBalanceSource, sa.RevaluationSource} {
+		assert.LessOrEqual(t, len(src), 6, src)
+	}
+}
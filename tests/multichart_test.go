@@ -106,11 +106,13 @@ func setupMultiChartTest(t *testing.T) {
 		DBName:               os.Getenv("DBNAME"),
 		AllowNativePasswords: true,
 		ParseTime:            true,
+		MultiStatements:      true,
 	}
 	dba, err := sql.Open("mysql", config.FormatDSN())
 	assert.NoError(t, err)
-	accountant1 = sa.NewAccountant(dba, 0, "GBP")
-	accountant2 = sa.NewAccountant(dba, 0, "GBP")
+	assert.NoError(t, sa.Migrate(dba))
+	accountant1 = sa.NewAccountant(sa.NewMysqlStore(dba), 0, "GBP")
+	accountant2 = sa.NewAccountant(sa.NewMysqlStore(dba), 0, "GBP")
 
 	def1, err := sa.NewChartDefinition("../tests/_data/personal.xml")
 	assert.NoError(t, err)